@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	AGGREGATE_SUM   = "sum"
+	AGGREGATE_MIN   = "min"
+	AGGREGATE_MAX   = "max"
+	AGGREGATE_AVG   = "avg"
+	AGGREGATE_COUNT = "count"
+	AGGREGATE_FIRST = "first"
+	AGGREGATE_LAST  = "last"
+
+	AGGREGATE_JOIN          = "join"
+	AGGREGATE_SORTED_UNIQUE = "sorted-unique"
+
+	AGGREGATE_DEFAULT_SEPARATOR = ","
+)
+
+func (m *ConfigMetricValue) compileAggregate() error {
+	m.Aggregate = strings.ToLower(m.Aggregate)
+
+	switch m.Aggregate {
+	case "", AGGREGATE_SUM, AGGREGATE_MIN, AGGREGATE_MAX, AGGREGATE_AVG, AGGREGATE_COUNT, AGGREGATE_FIRST, AGGREGATE_LAST:
+		return nil
+	default:
+		return fmt.Errorf(`value aggregation "%s" not supported`, m.Aggregate)
+	}
+}
+
+func (m *ConfigMetricLabel) compileAggregate() error {
+	m.Aggregate = strings.ToLower(m.Aggregate)
+
+	switch m.Aggregate {
+	case "", AGGREGATE_JOIN, AGGREGATE_FIRST, AGGREGATE_SORTED_UNIQUE:
+	default:
+		return fmt.Errorf(`label aggregation "%s" not supported`, m.Aggregate)
+	}
+
+	if m.Separator != nil && m.Aggregate != AGGREGATE_JOIN && m.Aggregate != AGGREGATE_SORTED_UNIQUE {
+		return fmt.Errorf(`separator is only supported for aggregation "%s" and "%s"`, AGGREGATE_JOIN, AGGREGATE_SORTED_UNIQUE)
+	}
+
+	return nil
+}
+
+// AggregateValues aggregates multiple (already converted) values into one value
+func (m *ConfigMetricValue) AggregateValues(values []float64) (ret *float64) {
+	if len(values) == 0 {
+		return nil
+	}
+
+	var val float64
+	switch m.Aggregate {
+	case AGGREGATE_SUM, AGGREGATE_AVG:
+		for _, v := range values {
+			val += v
+		}
+
+		if m.Aggregate == AGGREGATE_AVG {
+			val = val / float64(len(values))
+		}
+	case AGGREGATE_MIN:
+		val = values[0]
+		for _, v := range values[1:] {
+			val = math.Min(val, v)
+		}
+	case AGGREGATE_MAX:
+		val = values[0]
+		for _, v := range values[1:] {
+			val = math.Max(val, v)
+		}
+	case AGGREGATE_COUNT:
+		val = float64(len(values))
+	case AGGREGATE_FIRST:
+		val = values[0]
+	case AGGREGATE_LAST:
+		val = values[len(values)-1]
+	default:
+		return nil
+	}
+
+	return &val
+}
+
+// AggregateLabels aggregates multiple (already converted) label values into one label value
+func (m *ConfigMetricLabel) AggregateLabels(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	separator := AGGREGATE_DEFAULT_SEPARATOR
+	if m.Separator != nil {
+		separator = *m.Separator
+	}
+
+	switch m.Aggregate {
+	case AGGREGATE_FIRST:
+		return values[0]
+	case AGGREGATE_SORTED_UNIQUE:
+		uniqueValues := []string{}
+		seen := map[string]bool{}
+		for _, val := range values {
+			if !seen[val] {
+				seen[val] = true
+				uniqueValues = append(uniqueValues, val)
+			}
+		}
+		sort.Strings(uniqueValues)
+
+		return strings.Join(uniqueValues, separator)
+	default:
+		return strings.Join(values, separator)
+	}
+}
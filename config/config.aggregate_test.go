@@ -0,0 +1,349 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	yaml "github.com/goccy/go-yaml"
+)
+
+func TestAggregateValues(t *testing.T) {
+	object := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "port": int64(8080)},
+				map[string]interface{}{"name": "sidecar", "port": int64(9090)},
+				map[string]interface{}{"name": "metrics", "port": "9100"},
+				map[string]interface{}{"name": "init"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		raw      string
+		expected *float64
+		error    string
+	}{
+		{
+			name: "sum",
+			raw: `
+name: kube_test
+value:
+  jsonPath: .spec.containers[*].port
+  aggregate: sum
+`,
+			expected: floatPtr(26270),
+		},
+		{
+			name: "min",
+			raw: `
+name: kube_test
+value:
+  jsonPath: .spec.containers[*].port
+  aggregate: min
+`,
+			expected: floatPtr(8080),
+		},
+		{
+			name: "max",
+			raw: `
+name: kube_test
+value:
+  jsonPath: .spec.containers[*].port
+  aggregate: MAX
+`,
+			expected: floatPtr(9100),
+		},
+		{
+			name: "avg",
+			raw: `
+name: kube_test
+value:
+  jsonPath: .spec.containers[*].port
+  aggregate: avg
+`,
+			expected: floatPtr(26270.0 / 3),
+		},
+		{
+			name: "count",
+			raw: `
+name: kube_test
+value:
+  jsonPath: .spec.containers[*].name
+  aggregate: count
+`,
+			expected: floatPtr(4),
+		},
+		{
+			name: "first",
+			raw: `
+name: kube_test
+value:
+  jsonPath: .spec.containers[*].port
+  aggregate: first
+`,
+			expected: floatPtr(8080),
+		},
+		{
+			name: "last",
+			raw: `
+name: kube_test
+value:
+  jsonPath: .spec.containers[*].port
+  aggregate: last
+`,
+			expected: floatPtr(9100),
+		},
+		{
+			name: "no results",
+			raw: `
+name: kube_test
+value:
+  jsonPath: .spec.volumes[*].size
+  aggregate: sum
+`,
+		},
+		{
+			name: "without aggregation",
+			raw: `
+name: kube_test
+value:
+  jsonPath: .spec.containers[*].port
+`,
+		},
+		{
+			name: "unsupported",
+			raw: `
+name: kube_test
+value:
+  jsonPath: .spec.containers[*].port
+  aggregate: median
+`,
+			error: `value aggregation "median" not supported`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metric := &ConfigMetric{}
+			if err := yaml.UnmarshalWithOptions([]byte(test.raw), metric, yaml.Strict(), yaml.UseJSONUnmarshaler()); err != nil {
+				t.Fatal(err)
+			}
+
+			err := metric.Compile()
+			switch {
+			case test.error == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q", test.error)
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			case test.error != "":
+				return
+			}
+
+			val, err := metric.Value.FindValue(NewObjectElement(object))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			switch {
+			case test.expected == nil && val != nil:
+				t.Errorf("expected no value, got %v", *val)
+			case test.expected != nil && val == nil:
+				t.Errorf("expected %v, got no value", *test.expected)
+			case test.expected != nil && *val != *test.expected:
+				t.Errorf("expected %v, got %v", *test.expected, *val)
+			}
+		})
+	}
+}
+
+func TestAggregateLabels(t *testing.T) {
+	object := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "sidecar", "image": "envoy"},
+				map[string]interface{}{"name": "app", "image": "nginx"},
+				map[string]interface{}{"name": "init", "image": "envoy"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		raw      string
+		expected string
+		found    bool
+		error    string
+	}{
+		{
+			name: "join",
+			raw: `
+name: kube_test
+value:
+  value: 1
+labels:
+  test:
+    jsonPath: .spec.containers[*].image
+    aggregate: join
+`,
+			expected: "envoy,nginx,envoy",
+			found:    true,
+		},
+		{
+			name: "join separator",
+			raw: `
+name: kube_test
+value:
+  value: 1
+labels:
+  test:
+    jsonPath: .spec.containers[*].name
+    aggregate: join
+    separator: " | "
+`,
+			expected: "sidecar | app | init",
+			found:    true,
+		},
+		{
+			name: "sorted-unique",
+			raw: `
+name: kube_test
+value:
+  value: 1
+labels:
+  test:
+    jsonPath: .spec.containers[*].image
+    aggregate: sorted-unique
+`,
+			expected: "envoy,nginx",
+			found:    true,
+		},
+		{
+			name: "sorted-unique separator",
+			raw: `
+name: kube_test
+value:
+  value: 1
+labels:
+  test:
+    jsonPath: .spec.containers[*].image
+    aggregate: sorted-unique
+    separator: ";"
+`,
+			expected: "envoy;nginx",
+			found:    true,
+		},
+		{
+			name: "empty separator",
+			raw: `
+name: kube_test
+value:
+  value: 1
+labels:
+  test:
+    jsonPath: .spec.containers[*].name
+    aggregate: join
+    separator: ""
+`,
+			expected: "sidecarappinit",
+			found:    true,
+		},
+		{
+			name: "first",
+			raw: `
+name: kube_test
+value:
+  value: 1
+labels:
+  test:
+    jsonPath: .spec.containers[*].name
+    aggregate: first
+`,
+			expected: "sidecar",
+			found:    true,
+		},
+		{
+			name: "no results",
+			raw: `
+name: kube_test
+value:
+  value: 1
+labels:
+  test:
+    jsonPath: .spec.volumes[*].name
+    aggregate: join
+`,
+		},
+		{
+			name: "without aggregation",
+			raw: `
+name: kube_test
+value:
+  value: 1
+labels:
+  test:
+    jsonPath: .spec.containers[*].name
+`,
+		},
+		{
+			name: "separator without join",
+			raw: `
+name: kube_test
+value:
+  value: 1
+labels:
+  test:
+    jsonPath: .spec.containers[*].name
+    aggregate: first
+    separator: ";"
+`,
+			error: `separator is only supported for aggregation "join" and "sorted-unique"`,
+		},
+		{
+			name: "unsupported",
+			raw: `
+name: kube_test
+value:
+  value: 1
+labels:
+  test:
+    jsonPath: .spec.containers[*].name
+    aggregate: sum
+`,
+			error: `label aggregation "sum" not supported`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metric := &ConfigMetric{}
+			if err := yaml.UnmarshalWithOptions([]byte(test.raw), metric, yaml.Strict(), yaml.UseJSONUnmarshaler()); err != nil {
+				t.Fatal(err)
+			}
+
+			err := metric.Compile()
+			switch {
+			case test.error == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q", test.error)
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			case test.error != "":
+				return
+			}
+
+			label, found, err := metric.Labels["test"].FindLabel(NewObjectElement(object))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if found != test.found || label != test.expected {
+				t.Errorf("expected %q (found=%v), got %q (found=%v)", test.expected, test.found, label, found)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	yaml "github.com/goccy/go-yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	ANNOTATION_METRICS_DEFAULT = "kube-resource-exporter.webdevops.io/metrics"
+)
+
+type (
+	ConfigAnnotationMetrics struct {
+		Enabled    bool   `yaml:"enabled"`
+		Annotation string `yaml:"annotation"`
+		Prefix     string `yaml:"prefix"`
+
+		baseLabelNames []string
+
+		cache     map[types.UID]*annotationMetricsCacheEntry
+		cacheLock sync.Mutex
+	}
+
+	annotationMetricsCacheEntry struct {
+		raw     string
+		metrics []*ConfigMetric
+		err     error
+		seen    bool
+	}
+)
+
+func (m *ConfigAnnotationMetrics) Compile(baseLabelNames []string) error {
+	if m.Annotation == "" {
+		m.Annotation = ANNOTATION_METRICS_DEFAULT
+	}
+
+	if m.Enabled {
+		if m.Prefix == "" {
+			return fmt.Errorf("prefix is required")
+		}
+
+		if !metricNameRegexp.MatchString(m.Prefix) {
+			return fmt.Errorf(`prefix "%s" is not a valid Prometheus metric name prefix`, m.Prefix)
+		}
+	}
+
+	m.baseLabelNames = baseLabelNames
+	m.cache = map[types.UID]*annotationMetricsCacheEntry{}
+
+	return nil
+}
+
+// IsEnabled returns true if metric definitions should be read from object annotations
+func (m *ConfigAnnotationMetrics) IsEnabled() bool {
+	return m != nil && m.Enabled
+}
+
+// MetricsForObject returns the compiled metric definitions (including companion metrics) embedded in the object annotation,
+// definitions are cached per object and only compiled again if the annotation changes
+func (m *ConfigAnnotationMetrics) MetricsForObject(object unstructured.Unstructured) ([]*ConfigMetric, error) {
+	m.cacheLock.Lock()
+	defer m.cacheLock.Unlock()
+
+	uid := object.GetUID()
+	raw, exists := object.GetAnnotations()[m.Annotation]
+	if !exists || strings.TrimSpace(raw) == "" {
+		delete(m.cache, uid)
+		return nil, nil
+	}
+
+	if entry, exists := m.cache[uid]; exists && entry.raw == raw {
+		entry.seen = true
+		return entry.metrics, entry.err
+	}
+
+	metrics, err := m.compileAnnotation(raw)
+	m.cache[uid] = &annotationMetricsCacheEntry{
+		raw:     raw,
+		metrics: metrics,
+		err:     err,
+		seen:    true,
+	}
+
+	return metrics, err
+}
+
+// Cleanup removes cached definitions of objects which were not seen since the last cleanup
+func (m *ConfigAnnotationMetrics) Cleanup() {
+	m.cacheLock.Lock()
+	defer m.cacheLock.Unlock()
+
+	for uid, entry := range m.cache {
+		if entry.seen {
+			entry.seen = false
+		} else {
+			delete(m.cache, uid)
+		}
+	}
+}
+
+func (m *ConfigAnnotationMetrics) compileAnnotation(raw string) ([]*ConfigMetric, error) {
+	metrics := []*ConfigMetric{}
+	if err := yaml.UnmarshalWithOptions([]byte(raw), &metrics, yaml.Strict(), yaml.UseJSONUnmarshaler()); err != nil {
+		return nil, fmt.Errorf(`unable to parse annotation "%s": %w`, m.Annotation, err)
+	}
+
+	ret := []*ConfigMetric{}
+	seenMetrics := map[string]bool{}
+	for _, metric := range metrics {
+		if metric == nil {
+			return nil, fmt.Errorf(`annotation "%s" contains empty metric definition`, m.Annotation)
+		}
+
+		if err := metric.Compile(); err != nil {
+			return nil, err
+		}
+
+		// features listing other objects or with unbounded cost (jq, decode, parse) are not allowed,
+		// everyone who can annotate objects could use them (joins are only configured on resources)
+		if metric.Mode == METRIC_MODE_REFERENCE {
+			return nil, fmt.Errorf(`mode "%s" of metric "%s" is not allowed in annotations`, METRIC_MODE_REFERENCE, metric.Name)
+		}
+
+		for labelName, labelConfig := range metric.Labels {
+			if labelConfig.Owner != nil {
+				return nil, fmt.Errorf(`owner of label "%s" of metric "%s" is not allowed in annotations`, labelName, metric.Name)
+			}
+
+			if feature := unboundedFeature(labelConfig.ConfigMetricJsonPath); feature != "" {
+				return nil, fmt.Errorf(`%s of label "%s" of metric "%s" is not allowed in annotations`, feature, labelName, metric.Name)
+			}
+		}
+
+		if feature := unboundedFeature(metric.Value.ConfigMetricJsonPath); feature != "" {
+			return nil, fmt.Errorf(`%s of value of metric "%s" is not allowed in annotations`, feature, metric.Name)
+		}
+
+		if !strings.HasPrefix(metric.Name, m.Prefix) {
+			return nil, fmt.Errorf(`metric name "%s" must start with prefix "%s"`, metric.Name, m.Prefix)
+		}
+
+		for _, row := range metric.AllMetrics() {
+			if err := row.checkBaseLabels(m.baseLabelNames); err != nil {
+				return nil, err
+			}
+
+			if seenMetrics[row.Name] {
+				return nil, fmt.Errorf(`metric "%s" is defined multiple times in annotation "%s"`, row.Name, m.Annotation)
+			}
+			seenMetrics[row.Name] = true
+			ret = append(ret, row)
+		}
+	}
+
+	return ret, nil
+}
+
+// unboundedFeature returns the name of the used feature with unbounded cpu or memory usage (empty if none)
+func unboundedFeature(jsonPath *ConfigMetricJsonPath) string {
+	switch {
+	case jsonPath == nil:
+		return ""
+	case jsonPath.Jq != "":
+		return "jq"
+	case jsonPath.Decode != "":
+		return "decode"
+	case jsonPath.Parse != "":
+		return "parse"
+	}
+
+	return ""
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnnotationMetricsRejectListingFeatures(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		error string
+	}{
+		{
+			name: "valid",
+			raw: `
+- name: kube_custom_replicas
+  value:
+    jsonPath: .spec.replicas
+`,
+		},
+		{
+			name: "reference",
+			raw: `
+- name: kube_custom_reference
+  mode: reference
+  reference:
+    version: v1
+    resource: secrets
+    jsonPath: .spec.secretName
+`,
+			error: `mode "reference" of metric "kube_custom_reference" is not allowed in annotations`,
+		},
+		{
+			name: "owner",
+			raw: `
+- name: kube_custom_owner
+  value:
+    value: 1
+  labels:
+    owner:
+      owner: {}
+`,
+			error: `owner of label "owner" of metric "kube_custom_owner" is not allowed in annotations`,
+		},
+		{
+			name: "jq value",
+			raw: `
+- name: kube_custom_jq
+  value:
+    jq: '[range(1e9)] | length'
+`,
+			error: `jq of value of metric "kube_custom_jq" is not allowed in annotations`,
+		},
+		{
+			name: "jq label",
+			raw: `
+- name: kube_custom_jq
+  value:
+    value: 1
+  labels:
+    loop:
+      jq: 'def f: f; f'
+`,
+			error: `jq of label "loop" of metric "kube_custom_jq" is not allowed in annotations`,
+		},
+		{
+			name: "decode",
+			raw: `
+- name: kube_custom_release
+  value:
+    jsonPath: .data.release
+    decode: helmRelease
+    documentPath: .version
+`,
+			error: `decode of value of metric "kube_custom_release" is not allowed in annotations`,
+		},
+		{
+			name: "parse",
+			raw: `
+- name: kube_custom_config
+  value:
+    value: 1
+  labels:
+    replicas:
+      jsonPath: .metadata.annotations.config
+      parse: yaml
+      documentPath: .replicas
+`,
+			error: `parse of label "replicas" of metric "kube_custom_config" is not allowed in annotations`,
+		},
+		{
+			name: "base label",
+			raw: `
+- name: kube_custom_info
+  value:
+    value: 1
+  labels:
+    name:
+      jsonPath: .metadata.name
+`,
+			error: `label "name" of metric "kube_custom_info" is conflicting with base label "name"`,
+		},
+		{
+			name: "prefix",
+			raw: `
+- name: other_metric
+  value:
+    value: 1
+`,
+			error: `must start with prefix "kube_custom_"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			annotationMetrics := &ConfigAnnotationMetrics{Enabled: true, Prefix: "kube_custom_"}
+			if err := annotationMetrics.Compile([]string{"gvr", "namespace", "name"}); err != nil {
+				t.Fatal(err)
+			}
+
+			metrics, err := annotationMetrics.compileAnnotation(test.raw)
+			switch {
+			case test.error == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q, got %d metrics", test.error, len(metrics))
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			}
+		})
+	}
+}
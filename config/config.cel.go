@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/cel-go/ext"
+)
+
+const (
+	// CEL_COST_LIMIT limits the runtime cost of each expression evaluation
+	CEL_COST_LIMIT = 1000000
+
+	// CEL_VAR_OBJECT is the whole object
+	CEL_VAR_OBJECT = "object"
+
+	// CEL_VAR_ELEMENT is the current element (foreach), without foreach it's the object
+	CEL_VAR_ELEMENT = "element"
+)
+
+type (
+	celExpression struct {
+		expression string
+		program    cel.Program
+	}
+)
+
+var (
+	celEnv     *cel.Env
+	celEnvErr  error
+	celEnvOnce sync.Once
+
+	celMapType = reflect.TypeOf(map[string]interface{}{})
+)
+
+func celEnvironment() (*cel.Env, error) {
+	celEnvOnce.Do(func() {
+		celEnv, celEnvErr = cel.NewEnv(
+			cel.Variable(CEL_VAR_OBJECT, cel.DynType),
+			cel.Variable(CEL_VAR_ELEMENT, cel.DynType),
+			ext.Strings(),
+			ext.Math(),
+			ext.Encoders(),
+			ext.Lists(),
+			ext.Sets(),
+		)
+	})
+
+	return celEnv, celEnvErr
+}
+
+// compileCelExpression parses and type checks the expression, result type must be one of allowedTypes (or dyn)
+func compileCelExpression(expression string, allowedTypes ...*cel.Type) (*celExpression, error) {
+	env, err := celEnvironment()
+	if err != nil {
+		return nil, fmt.Errorf(`unable to create CEL environment: %w`, err)
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf(`unable to compile CEL expression "%s": %w`, expression, issues.Err())
+	}
+
+	if outputType := ast.OutputType(); outputType.Kind() != types.DynKind {
+		typeAllowed := false
+		for _, allowedType := range allowedTypes {
+			if allowedType.IsAssignableType(outputType) {
+				typeAllowed = true
+				break
+			}
+		}
+
+		if !typeAllowed {
+			return nil, fmt.Errorf(`CEL expression "%s" returns unsupported type "%s"`, expression, outputType.String())
+		}
+	}
+
+	program, err := env.Program(ast, cel.CostLimit(CEL_COST_LIMIT))
+	if err != nil {
+		return nil, fmt.Errorf(`unable to build CEL program "%s": %w`, expression, err)
+	}
+
+	return &celExpression{
+		expression: expression,
+		program:    program,
+	}, nil
+}
+
+// FindResults evaluates the expression, lists are returned as multiple results
+func (e *celExpression) FindResults(element ObjectElement) ([]interface{}, error) {
+	val, _, err := e.program.Eval(map[string]interface{}{
+		CEL_VAR_OBJECT:  element.Root,
+		CEL_VAR_ELEMENT: element.Value,
+	})
+	if err != nil {
+		return nil, fmt.Errorf(`unable to evaluate CEL expression "%s": %w`, e.expression, err)
+	}
+
+	if list, ok := val.(traits.Lister); ok {
+		ret := []interface{}{}
+		it := list.Iterator()
+		for it.HasNext() == types.True {
+			ret = append(ret, celToNative(it.Next()))
+		}
+		return ret, nil
+	}
+
+	return []interface{}{celToNative(val)}, nil
+}
+
+// celToNative converts CEL values into the native types used by the jsonPath results
+func celToNative(val ref.Val) interface{} {
+	switch v := val.(type) {
+	case types.Null:
+		return nil
+	case types.Timestamp:
+		return v.Time.Format(time.RFC3339)
+	case types.Duration:
+		return v.Duration.Seconds()
+	case traits.Lister:
+		ret := []interface{}{}
+		it := v.Iterator()
+		for it.HasNext() == types.True {
+			ret = append(ret, celToNative(it.Next()))
+		}
+		return ret
+	case traits.Mapper:
+		if ret, err := v.ConvertToNative(celMapType); err == nil {
+			return ret
+		}
+		return nil
+	default:
+		return val.Value()
+	}
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCelCompile(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric *ConfigMetric
+		error  string
+	}{
+		{
+			name: "value",
+			metric: &ConfigMetric{
+				Value: &ConfigMetricValue{Expression: `object.spec.replicas * 2`},
+			},
+		},
+		{
+			name: "value syntax",
+			metric: &ConfigMetric{
+				Value: &ConfigMetricValue{Expression: `object.spec.replicas *`},
+			},
+			error: `unable to compile CEL expression`,
+		},
+		{
+			name: "value undeclared",
+			metric: &ConfigMetric{
+				Value: &ConfigMetricValue{Expression: `spec.replicas`},
+			},
+			error: `unable to compile CEL expression`,
+		},
+		{
+			name: "value map",
+			metric: &ConfigMetric{
+				Value: &ConfigMetricValue{Expression: `{"replicas": object.spec.replicas}`},
+			},
+			error: `returns unsupported type "map(string, dyn)"`,
+		},
+		{
+			name: "label",
+			metric: &ConfigMetric{
+				Value: &ConfigMetricValue{Value: floatPtr(1)},
+				Labels: map[string]*ConfigMetricLabel{
+					"node": {Expression: `object.spec.nodeName.lowerAscii()`},
+				},
+			},
+		},
+		{
+			name: "label map",
+			metric: &ConfigMetric{
+				Value: &ConfigMetricValue{Value: floatPtr(1)},
+				Labels: map[string]*ConfigMetricLabel{
+					"labels": {Expression: `{"a": "b"}`},
+				},
+			},
+			error: `returns unsupported type "map(string, string)"`,
+		},
+		{
+			name: "filter bool",
+			metric: &ConfigMetric{
+				Value:   &ConfigMetricValue{Value: floatPtr(1)},
+				Filters: []*ConfigMetricFilter{{Expression: `object.spec.replicas > 1`}},
+			},
+		},
+		{
+			name: "filter string",
+			metric: &ConfigMetric{
+				Value:   &ConfigMetricValue{Value: floatPtr(1)},
+				Filters: []*ConfigMetricFilter{{Expression: `"replicas"`}},
+			},
+			error: `returns unsupported type "string"`,
+		},
+		{
+			name: "filter string regex",
+			metric: &ConfigMetric{
+				Value:   &ConfigMetricValue{Value: floatPtr(1)},
+				Filters: []*ConfigMetricFilter{{Expression: `"replicas"`, Regex: `^rep`}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.metric.Name = "kube_test"
+
+			err := test.metric.Compile()
+			switch {
+			case test.error == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q", test.error)
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			}
+		})
+	}
+}
+
+func TestCelCostLimit(t *testing.T) {
+	// string functions are charged by size, a long string exceeds the limit with a single call
+	object := map[string]interface{}{
+		"data": map[string]interface{}{
+			"payload": strings.Repeat("a", CEL_COST_LIMIT*20),
+		},
+	}
+
+	expression, err := compileCelExpression(`object.data.payload.contains("b")`, celResultTypes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = expression.FindResults(NewObjectElement(object))
+	if err == nil {
+		t.Fatal("expected cost limit error")
+	}
+	if !strings.Contains(err.Error(), "cost limit exceeded") {
+		t.Fatalf("expected cost limit error, got %q", err.Error())
+	}
+}
+
+func TestCelFilter(t *testing.T) {
+	object := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "app",
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		filter   *ConfigMetricFilter
+		expected bool
+	}{
+		{name: "true", filter: &ConfigMetricFilter{Expression: `object.spec.replicas > 1`}, expected: true},
+		{name: "false", filter: &ConfigMetricFilter{Expression: `object.spec.replicas > 5`}, expected: false},
+		{name: "missing field", filter: &ConfigMetricFilter{Expression: `object.spec.missing > 1`}, expected: false},
+		{name: "has", filter: &ConfigMetricFilter{Expression: `has(object.spec.missing)`}, expected: false},
+		{name: "regex match", filter: &ConfigMetricFilter{Expression: `object.metadata.name`, Regex: `^app$`}, expected: true},
+		{name: "regex mismatch", filter: &ConfigMetricFilter{Expression: `object.metadata.name`, Regex: `^web$`}, expected: false},
+		{name: "regex missing field", filter: &ConfigMetricFilter{Expression: `object.metadata.missing`, Regex: `.*`}, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metric := &ConfigMetric{
+				Name:    "kube_test",
+				Value:   &ConfigMetricValue{Value: floatPtr(1)},
+				Filters: []*ConfigMetricFilter{test.filter},
+			}
+			if err := metric.Compile(); err != nil {
+				t.Fatal(err)
+			}
+
+			if valid := metric.IsValidObject(NewObjectElement(object)); valid != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, valid)
+			}
+		})
+	}
+}
+
+func TestCelValue(t *testing.T) {
+	object := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+		},
+	}
+
+	metric := &ConfigMetric{
+		Name:  "kube_test",
+		Value: &ConfigMetricValue{Expression: `object.spec.replicas * 2`},
+	}
+	if err := metric.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := metric.Value.FindValue(NewObjectElement(object))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val == nil || *val != 6 {
+		t.Fatalf("expected 6, got %v", val)
+	}
+
+	// missing fields are evaluation errors
+	if _, err := metric.Value.FindValue(NewObjectElement(map[string]interface{}{})); err == nil {
+		t.Fatal("expected evaluation error")
+	}
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	METRIC_MODE_DEFAULT    = ""
+	METRIC_MODE_CONDITIONS = "conditions"
+
+	CONDITIONS_DEFAULT_PATH = ".status.conditions"
+
+	CONDITIONS_SUFFIX_LASTTRANSITIONTIME = "_last_transition_time"
+	CONDITIONS_SUFFIX_GENERATIONLAG      = "_generation_lag"
+)
+
+type (
+	ConfigMetricConditions struct {
+		Path string `yaml:"jsonPath" json:"jsonPath"`
+
+		// companion series with lastTransitionTime as unix timestamp
+		LastTransitionTime bool `yaml:"lastTransitionTime"`
+
+		// companion series with difference between metadata.generation and observedGeneration
+		GenerationLag bool `yaml:"generationLag"`
+	}
+)
+
+var (
+	conditionLabels = map[string]string{
+		"type":   ".type",
+		"status": ".status",
+		"reason": ".reason",
+	}
+)
+
+// compileConditions configures the metric to emit one series per condition
+// and generates the companion metrics
+func (m *ConfigMetric) compileConditions() error {
+	if m.Foreach != nil {
+		return fmt.Errorf(`foreach is not supported for mode "%s"`, METRIC_MODE_CONDITIONS)
+	}
+
+	if m.Conditions == nil {
+		m.Conditions = &ConfigMetricConditions{}
+	}
+
+	if m.Conditions.Path == "" {
+		m.Conditions.Path = CONDITIONS_DEFAULT_PATH
+	}
+
+	m.Foreach = &ConfigMetricForeach{Path: m.Conditions.Path}
+
+	if m.Value == nil {
+		value := float64(1)
+		m.Value = &ConfigMetricValue{Value: &value}
+	}
+
+	if m.Labels == nil {
+		m.Labels = map[string]*ConfigMetricLabel{}
+	}
+
+	for labelName, labelPath := range conditionLabels {
+		if _, exists := m.Labels[labelName]; exists {
+			return fmt.Errorf(`label "%s" is reserved for mode "%s"`, labelName, METRIC_MODE_CONDITIONS)
+		}
+
+		m.Labels[labelName] = &ConfigMetricLabel{
+			ConfigMetricJsonPath: &ConfigMetricJsonPath{Path: labelPath},
+		}
+	}
+
+	return nil
+}
+
+// compileConditionsCompanions generates the companion metrics (has to be called after compile of the metric itself)
+func (m *ConfigMetric) compileConditionsCompanions() error {
+	m._companions = []*ConfigMetric{}
+
+	if m.Conditions.LastTransitionTime {
+		value := &ConfigMetricValue{
+			ConfigMetricJsonPath: &ConfigMetricJsonPath{
+				Path:    ".lastTransitionTime",
+				Convert: []*ConfigMetricConvert{{Method: CONVERT_TOTIMESTAMP}},
+			},
+		}
+
+		path, err := compileObjectPath(value.Path)
+		if err != nil {
+			return err
+		}
+		value._path = path
+
+		if err := value.compileConvert(valueConversions); err != nil {
+			return err
+		}
+
+		m._companions = append(m._companions, m.companionMetric(CONDITIONS_SUFFIX_LASTTRANSITIONTIME, "last transition time", value))
+	}
+
+	if m.Conditions.GenerationLag {
+		value := &ConfigMetricValue{
+			_func: conditionGenerationLag,
+		}
+
+		m._companions = append(m._companions, m.companionMetric(CONDITIONS_SUFFIX_GENERATIONLAG, "generation lag", value))
+	}
+
+	for _, companion := range m._companions {
+		if !metricNameRegexp.MatchString(companion.Name) {
+			return fmt.Errorf(`metric name "%s" is not a valid Prometheus metric name`, companion.Name)
+		}
+	}
+
+	return nil
+}
+
+// companionMetric returns a copy of the metric with a different name and value
+func (m *ConfigMetric) companionMetric(suffix, help string, value *ConfigMetricValue) *ConfigMetric {
+	companion := *m
+	companion.Name = m.Name + suffix
+	companion.Help = strings.TrimSpace(fmt.Sprintf("%s (%s)", m.Help, help))
+	companion.Value = value
+	companion.Conditions = nil
+	companion._companions = nil
+
+	return &companion
+}
+
+// conditionGenerationLag returns the difference between metadata.generation of the object
+// and observedGeneration of the condition (or status.observedGeneration as fallback)
+func conditionGenerationLag(element ObjectElement) (*float64, error) {
+	generation, ok := numericField(element.Root, "metadata", "generation")
+	if !ok {
+		return nil, nil
+	}
+
+	observedGeneration, ok := numericField(element.Value, "observedGeneration")
+	if !ok {
+		if observedGeneration, ok = numericField(element.Root, "status", "observedGeneration"); !ok {
+			return nil, nil
+		}
+	}
+
+	lag := generation - observedGeneration
+	if lag < 0 {
+		lag = 0
+	}
+
+	return &lag, nil
+}
+
+func numericField(data interface{}, fields ...string) (float64, bool) {
+	for _, field := range fields {
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			return 0, false
+		}
+
+		if data, ok = obj[field]; !ok {
+			return 0, false
+		}
+	}
+
+	switch v := data.(type) {
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+
+	return 0, false
+}
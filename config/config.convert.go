@@ -2,13 +2,18 @@ package config
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"html/template"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
-	sprig "github.com/Masterminds/sprig/v3"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
@@ -17,82 +22,510 @@ const (
 	CONVERT_TOUPPER     = "toupper"
 	CONVERT_TOLOWER     = "tolower"
 	CONVERT_TRIM        = "trim"
+	CONVERT_TOQUANTITY  = "toquantity"
+	CONVERT_TODURATION  = "toduration"
+	CONVERT_TOBYTES     = "tobytes"
+	CONVERT_AGE         = "age"
+	CONVERT_TIMEUNTIL   = "timeuntil"
+
+	CONVERT_BASE64DECODE = "base64decode"
+	CONVERT_REGEXEXTRACT = "regexextract"
+	CONVERT_REGEXREPLACE = "regexreplace"
+	CONVERT_TRUNCATE     = "truncate"
+	CONVERT_SHA256       = "sha256"
+	CONVERT_MAP          = "map"
+	CONVERT_X509         = "x509"
+	CONVERT_JWT          = "jwt"
+	CONVERT_IMAGE        = "image"
+	CONVERT_SEMVER       = "semver"
+
+	JWT_FIELD_EXP = "exp"
+	JWT_FIELD_IAT = "iat"
+	JWT_FIELD_NBF = "nbf"
+	JWT_FIELD_ISS = "iss"
+	JWT_FIELD_SUB = "sub"
+)
+
+type (
+	// ConvertError is returned by conversions which report invalid data (eg. x509)
+	ConvertError struct {
+		Method string
+		Err    error
+	}
+
+	// ConfigMetricConvert is a conversion step, defined as plain method name or as object with parameters
+	ConfigMetricConvert struct {
+		Method string `yaml:"method" json:"method"`
+
+		// time conversions (toTimestamp, toDateTime, age, timeUntil)
+		Layouts        []string `yaml:"layouts" json:"layouts"`
+		Epoch          string   `yaml:"epoch" json:"epoch"`
+		Timezone       string   `yaml:"timezone" json:"timezone"`
+		OutputLayout   string   `yaml:"outputLayout" json:"outputLayout"`
+		OutputTimezone string   `yaml:"outputTimezone" json:"outputTimezone"`
+
+		// string conversions (regexExtract, regexReplace, truncate, map)
+		Regex       string            `yaml:"regex" json:"regex"`
+		Group       *int              `yaml:"group" json:"group"`
+		Replacement string            `yaml:"replacement" json:"replacement"`
+		Length      int               `yaml:"length" json:"length"`
+		Map         map[string]string `yaml:"map" json:"map"`
+		Default     *string           `yaml:"default" json:"default"`
+
+		// decoding conversions (x509, jwt, image)
+		Field  string `yaml:"field" json:"field"`
+		Select string `yaml:"select" json:"select"`
+
+		_method         string
+		_epochUnit      float64
+		_location       *time.Location
+		_outputLocation *time.Location
+		_regex          *regexp.Regexp
+		_group          int
+		_field          string
+		_select         string
+	}
+)
+
+var (
+	valueConversions = map[string]bool{
+		CONVERT_TOTIMESTAMP: true,
+		CONVERT_TOQUANTITY:  true,
+		CONVERT_TODURATION:  true,
+		CONVERT_TOBYTES:     true,
+		CONVERT_AGE:         true,
+		CONVERT_TIMEUNTIL:   true,
+
+		CONVERT_BASE64DECODE: true,
+		CONVERT_REGEXEXTRACT: true,
+		CONVERT_REGEXREPLACE: true,
+		CONVERT_TRUNCATE:     true,
+		CONVERT_SHA256:       true,
+		CONVERT_MAP:          true,
+		CONVERT_X509:         true,
+		CONVERT_JWT:          true,
+		CONVERT_IMAGE:        true,
+		CONVERT_SEMVER:       true,
+	}
+
+	labelConversions = map[string]bool{
+		CONVERT_TOTIMESTAMP: true,
+		CONVERT_TODATETIME:  true,
+		CONVERT_TOUPPER:     true,
+		CONVERT_TOLOWER:     true,
+		CONVERT_TRIM:        true,
+		CONVERT_TOQUANTITY:  true,
+		CONVERT_TODURATION:  true,
+		CONVERT_TOBYTES:     true,
+
+		CONVERT_BASE64DECODE: true,
+		CONVERT_REGEXEXTRACT: true,
+		CONVERT_REGEXREPLACE: true,
+		CONVERT_TRUNCATE:     true,
+		CONVERT_SHA256:       true,
+		CONVERT_MAP:          true,
+		CONVERT_X509:         true,
+		CONVERT_JWT:          true,
+		CONVERT_IMAGE:        true,
+		CONVERT_SEMVER:       true,
+	}
+
+	// conversions which parse datetimes and support time parameters
+	timeConversions = map[string]bool{
+		CONVERT_TOTIMESTAMP: true,
+		CONVERT_TODATETIME:  true,
+		CONVERT_AGE:         true,
+		CONVERT_TIMEUNTIL:   true,
+	}
+
+	// supported parameters of each conversion
+	convertParameters = map[string][]string{
+		CONVERT_TOTIMESTAMP:  {"layouts", "epoch", "timezone"},
+		CONVERT_AGE:          {"layouts", "epoch", "timezone"},
+		CONVERT_TIMEUNTIL:    {"layouts", "epoch", "timezone"},
+		CONVERT_TODATETIME:   {"layouts", "epoch", "timezone", "outputLayout", "outputTimezone"},
+		CONVERT_REGEXEXTRACT: {"regex", "group"},
+		CONVERT_REGEXREPLACE: {"regex", "replacement"},
+		CONVERT_TRUNCATE:     {"length"},
+		CONVERT_MAP:          {"map", "default"},
+		CONVERT_X509:         {"field", "select"},
+		CONVERT_JWT:          {"field"},
+		CONVERT_IMAGE:        {"field"},
+		CONVERT_SEMVER:       {"field"},
+	}
+
+	// epoch units per second
+	epochUnits = map[string]float64{
+		"s":  1,
+		"ms": 1e3,
+		"us": 1e6,
+		"ns": 1e9,
+	}
+
+	// ISO-8601 duration, eg. P1DT2H30M
+	iso8601DurationRegexp = regexp.MustCompile(`^(-)?P(?:([0-9.]+)Y)?(?:([0-9.]+)M)?(?:([0-9.]+)W)?(?:([0-9.]+)D)?(?:T(?:([0-9.]+)H)?(?:([0-9.]+)M)?(?:([0-9.]+)S)?)?$`)
+	iso8601DurationUnits  = []float64{
+		365 * 24 * 60 * 60, // year
+		30 * 24 * 60 * 60,  // month
+		7 * 24 * 60 * 60,   // week
+		24 * 60 * 60,       // day
+		60 * 60,            // hour
+		60,                 // minute
+		1,                  // second
+	}
+
+	// human readable byte sizes, eg. 1.5GB or 512 MiB
+	byteSizeRegexp = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([a-zA-Z]*)$`)
+	byteSizeUnits  = map[string]float64{
+		"":    1,
+		"b":   1,
+		"k":   1000,
+		"kb":  1000,
+		"m":   1000 * 1000,
+		"mb":  1000 * 1000,
+		"g":   1000 * 1000 * 1000,
+		"gb":  1000 * 1000 * 1000,
+		"t":   1000 * 1000 * 1000 * 1000,
+		"tb":  1000 * 1000 * 1000 * 1000,
+		"p":   1000 * 1000 * 1000 * 1000 * 1000,
+		"pb":  1000 * 1000 * 1000 * 1000 * 1000,
+		"ki":  1 << 10,
+		"kib": 1 << 10,
+		"mi":  1 << 20,
+		"mib": 1 << 20,
+		"gi":  1 << 30,
+		"gib": 1 << 30,
+		"ti":  1 << 40,
+		"tib": 1 << 40,
+		"pi":  1 << 50,
+		"pib": 1 << 50,
+	}
 )
 
-func (m *ConfigMetricJsonPath) DoConvertValue(v string) (ret *float64) {
+// compileRelativeTime detects age and timeUntil conversions, the value is calculated when metrics are served
+func (m *ConfigMetricValue) compileRelativeTime() error {
+	m._relativeTime = ""
+	for _, convert := range m.Convert {
+		switch convert._method {
+		case CONVERT_AGE, CONVERT_TIMEUNTIL:
+			if m._relativeTime != "" {
+				return fmt.Errorf(`only one of conversion "age" or "timeUntil" can be used`)
+			}
+			m._relativeTime = convert._method
+		}
+	}
+
+	return nil
+}
+
+// RelativeTime returns the relative time conversion (age or timeuntil) of the value, empty if value is absolute
+func (m *ConfigMetricValue) RelativeTime() string {
+	return m._relativeTime
+}
+
+func (e *ConvertError) Error() string {
+	return fmt.Sprintf(`conversion "%s" failed: %s`, e.Method, e.Err)
+}
+
+func (e *ConvertError) Unwrap() error {
+	return e.Err
+}
+
+// UnmarshalJSON allows conversions to be defined as plain method name or as object with parameters
+func (m *ConfigMetricConvert) UnmarshalJSON(data []byte) error {
+	type configMetricConvert ConfigMetricConvert
+	return unmarshalStringOrObject(data, &m.Method, (*configMetricConvert)(m))
+}
+
+// compileConvert validates the configured conversions against the supported conversions
+func (m *ConfigMetricJsonPath) compileConvert(supported map[string]bool) error {
+	for _, convert := range m.Convert {
+		if convert == nil || convert.Method == "" {
+			return fmt.Errorf(`empty conversion found`)
+		}
+
+		if !supported[strings.ToLower(convert.Method)] {
+			return fmt.Errorf(`conversion "%s" not supported`, convert.Method)
+		}
+
+		if err := convert.compile(); err != nil {
+			return fmt.Errorf(`conversion "%s": %w`, convert.Method, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *ConfigMetricConvert) compile() error {
+	m._method = strings.ToLower(m.Method)
+
+	for _, parameter := range m.parameters() {
+		if !slices.Contains(convertParameters[m._method], parameter) {
+			return fmt.Errorf(`parameter "%s" is not supported`, parameter)
+		}
+	}
+
+	switch {
+	case timeConversions[m._method]:
+		return m.compileTime()
+	case stringConversions[m._method]:
+		return m.compileString()
+	case m._method == CONVERT_X509:
+		return m.compileX509()
+	case m._method == CONVERT_JWT:
+		return m.compileJwt()
+	case m._method == CONVERT_IMAGE:
+		return m.compileImage()
+	}
+
+	return nil
+}
+
+// parameters returns the names of the configured parameters
+func (m *ConfigMetricConvert) parameters() (ret []string) {
+	set := map[string]bool{
+		"layouts":        len(m.Layouts) > 0,
+		"epoch":          m.Epoch != "",
+		"timezone":       m.Timezone != "",
+		"outputLayout":   m.OutputLayout != "",
+		"outputTimezone": m.OutputTimezone != "",
+		"regex":          m.Regex != "",
+		"group":          m.Group != nil,
+		"replacement":    m.Replacement != "",
+		"length":         m.Length != 0,
+		"map":            m.Map != nil,
+		"default":        m.Default != nil,
+		"field":          m.Field != "",
+		"select":         m.Select != "",
+	}
+
+	for name, isSet := range set {
+		if isSet {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+
+	return
+}
+
+func (m *ConfigMetricConvert) compileTime() error {
+	for _, layout := range m.Layouts {
+		if strings.TrimSpace(layout) == "" {
+			return fmt.Errorf(`empty time layout found`)
+		}
+	}
+
+	m._epochUnit = 1
+	if m.Epoch != "" {
+		unit, exists := epochUnits[strings.ToLower(m.Epoch)]
+		if !exists {
+			return fmt.Errorf(`epoch unit "%s" not supported (s, ms, us, ns)`, m.Epoch)
+		}
+		m._epochUnit = unit
+	}
+
+	m._location = time.UTC
+	if m.Timezone != "" {
+		location, err := time.LoadLocation(m.Timezone)
+		if err != nil {
+			return fmt.Errorf(`invalid timezone "%s": %w`, m.Timezone, err)
+		}
+		m._location = location
+	}
+
+	if m.OutputTimezone != "" {
+		location, err := time.LoadLocation(m.OutputTimezone)
+		if err != nil {
+			return fmt.Errorf(`invalid outputTimezone "%s": %w`, m.OutputTimezone, err)
+		}
+		m._outputLocation = location
+	}
+
+	return nil
+}
+
+// parseEpoch converts an epoch number in the configured unit into seconds
+func (m *ConfigMetricConvert) parseEpoch(val float64) float64 {
+	return val / m._epochUnit
+}
+
+// parseTime parses a datetime using the configured layouts (default formats if not set),
+// values without zone information are parsed in the configured timezone (default UTC)
+func (m *ConfigMetricConvert) parseTime(val string) (time.Time, bool) {
+	layouts := timeFormats
+	if len(m.Layouts) > 0 {
+		layouts = m.Layouts
+	}
+
+	for _, layout := range layouts {
+		if parseVal, parseErr := time.ParseInLocation(layout, val, m._location); parseErr == nil && parseVal.Unix() > 0 {
+			return parseVal, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// formatTime formats the datetime using the configured output layout (default RFC3339) and timezone
+func (m *ConfigMetricConvert) formatTime(val time.Time) string {
+	if m._outputLocation != nil {
+		val = val.In(m._outputLocation)
+	}
+
+	layout := time.RFC3339
+	if m.OutputLayout != "" {
+		layout = m.OutputLayout
+	}
+
+	return val.Format(layout)
+}
+
+func (m *ConfigMetricJsonPath) DoConvertValue(v string) (ret *float64, err error) {
 	if val, err := strconv.ParseFloat(v, 64); err == nil {
 		ret = &val
 	}
 
 convertLoop:
-	for _, method := range m.Convert {
-		switch strings.ToLower(*method) {
-		case CONVERT_TOTIMESTAMP:
+	for _, convert := range m.Convert {
+		switch convert._method {
+		case CONVERT_TOTIMESTAMP, CONVERT_AGE, CONVERT_TIMEUNTIL:
+			// age and timeUntil are calculated relative to the scrape time, value is kept as timestamp
 			// check if string is timestamp
 			if ret != nil {
 				// already possible unix timestamp
+				val := convert.parseEpoch(*ret)
+				ret = &val
 				continue convertLoop
 			}
 
 			// check date formats
-			for _, timeFormat := range timeFormats {
-				if parseVal, parseErr := time.Parse(timeFormat, v); parseErr == nil && parseVal.Unix() > 0 {
-					val := float64(parseVal.Unix())
-					ret = &val
-					continue convertLoop
-				}
+			if parseVal, ok := convert.parseTime(v); ok {
+				val := float64(parseVal.Unix())
+				ret = &val
+				continue convertLoop
 			}
 
 			// conversion failed, to not use value
 			ret = nil
 
+		case CONVERT_BASE64DECODE, CONVERT_REGEXEXTRACT, CONVERT_REGEXREPLACE, CONVERT_TRUNCATE, CONVERT_SHA256, CONVERT_MAP, CONVERT_SEMVER:
+			// string conversions are applied to the raw value, the result is parsed as number again
+			val, ok := convert.convertString(v)
+			if !ok {
+				v = ""
+				ret = nil
+				continue convertLoop
+			}
+
+			v = val
+			ret = nil
+			if parsedVal, err := strconv.ParseFloat(v, 64); err == nil {
+				ret = &parsedVal
+			}
+
+		case CONVERT_X509, CONVERT_JWT, CONVERT_IMAGE:
+			// invalid data is reported as error, the result is parsed as number again
+			val, convertErr := convert.decode(v)
+			if convertErr != nil {
+				return nil, convertErr
+			}
+
+			v = val
+			ret = nil
+			if parsedVal, err := strconv.ParseFloat(v, 64); err == nil {
+				ret = &parsedVal
+			}
+
+		case CONVERT_TOQUANTITY:
+			if val, ok := parseQuantity(v); ok {
+				ret = &val
+			} else {
+				ret = nil
+			}
+
+		case CONVERT_TODURATION:
+			if ret != nil {
+				// already seconds
+				continue convertLoop
+			}
+
+			if val, ok := parseDurationSeconds(v); ok {
+				ret = &val
+			} else {
+				ret = nil
+			}
+
+		case CONVERT_TOBYTES:
+			if val, ok := parseBytes(v); ok {
+				ret = &val
+			} else {
+				ret = nil
+			}
+
 		default:
-			panic(fmt.Errorf(`value conversion "%s" not supported`, *method))
+			// not supported, should be caught by compile
+			ret = nil
 		}
 	}
 
 	return
 }
 
-func (m *ConfigMetricJsonPath) DoConvertLabel(val string) (ret string) {
+func (m *ConfigMetricJsonPath) DoConvertLabel(val string) (ret string, err error) {
 	ret = val
 
 convertLoop:
-	for _, method := range m.Convert {
-		switch strings.ToLower(*method) {
+	for _, convert := range m.Convert {
+		switch convert._method {
 		case CONVERT_TOTIMESTAMP:
 			// check if unixtimestamp
-			if _, err := strconv.ParseFloat(ret, 64); err == nil {
+			if timestamp, err := strconv.ParseFloat(ret, 64); err == nil {
+				if convert.Epoch != "" {
+					ret = formatConvertedNumber(convert.parseEpoch(timestamp), true)
+				}
 				// already timestamp, keep it
 				continue convertLoop
 			}
 
-			for _, timeFormat := range timeFormats {
-				if parseVal, parseErr := time.Parse(timeFormat, ret); parseErr == nil && parseVal.Unix() > 0 {
-					ret = fmt.Sprintf("%d", parseVal.Unix())
-					continue convertLoop
-				}
+			if parseVal, ok := convert.parseTime(ret); ok {
+				ret = fmt.Sprintf("%d", parseVal.Unix())
+				continue convertLoop
 			}
 
 			// conversion failed, to not use value
 			ret = ""
 		case CONVERT_TODATETIME:
-			// check if unixtimestamp
+			// check if unixtimestamp, epoch timestamps are formatted in the configured timezone (default UTC) if no output timezone is set
 			if timestamp, err := strconv.ParseFloat(ret, 64); err == nil {
-				ret = time.Unix(int64(timestamp), 0).Format(time.RFC3339)
+				seconds, fraction := math.Modf(convert.parseEpoch(timestamp))
+				ret = convert.formatTime(time.Unix(int64(seconds), int64(fraction*1e9)).In(convert._location))
 				continue convertLoop
 			}
 
-			for _, timeFormat := range timeFormats {
-				if parseVal, parseErr := time.Parse(timeFormat, ret); parseErr == nil && parseVal.Unix() > 0 {
-					ret = parseVal.Format(time.RFC3339)
-					continue convertLoop
-				}
+			if parseVal, ok := convert.parseTime(ret); ok {
+				ret = convert.formatTime(parseVal)
+				continue convertLoop
 			}
 
 			// conversion failed, to not use value
 			ret = ""
 
+		case CONVERT_BASE64DECODE, CONVERT_REGEXEXTRACT, CONVERT_REGEXREPLACE, CONVERT_TRUNCATE, CONVERT_SHA256, CONVERT_MAP, CONVERT_SEMVER:
+			if val, ok := convert.convertString(ret); ok {
+				ret = val
+			} else {
+				// conversion failed, to not use value
+				ret = ""
+			}
+
+		case CONVERT_X509, CONVERT_JWT, CONVERT_IMAGE:
+			convertVal, convertErr := convert.decode(ret)
+			if convertErr != nil {
+				return "", convertErr
+			}
+			ret = convertVal
+
 		case CONVERT_TOUPPER:
 			ret = strings.ToUpper(ret)
 
@@ -102,25 +535,167 @@ convertLoop:
 		case CONVERT_TRIM:
 			ret = strings.TrimSpace(ret)
 
-		default:
-			panic(fmt.Errorf(`label value conversion "%s" not supported`, *method))
+		case CONVERT_TOQUANTITY:
+			ret = formatConvertedNumber(parseQuantity(ret))
+
+		case CONVERT_TODURATION:
+			ret = formatConvertedNumber(parseDurationSeconds(ret))
+
+		case CONVERT_TOBYTES:
+			ret = formatConvertedNumber(parseBytes(ret))
 		}
 	}
 
-	if m.Template != nil {
-		tmpl, err := template.New("template").Funcs(sprig.FuncMap()).Parse(*m.Template)
+	return
+}
+
+// decode applies decoding conversions (x509, jwt, image), invalid data is returned as ConvertError
+func (m *ConfigMetricConvert) decode(val string) (ret string, err error) {
+	switch m._method {
+	case CONVERT_X509:
+		ret, err = m.convertX509(val)
+	case CONVERT_JWT:
+		ret, err = m.convertJwt(val)
+	case CONVERT_IMAGE:
+		ret, err = m.convertImage(val)
+	}
+
+	if err != nil {
+		return "", &ConvertError{Method: m.Method, Err: err}
+	}
+
+	return ret, nil
+}
+
+func (m *ConfigMetricConvert) compileJwt() error {
+	m._field = JWT_FIELD_EXP
+	if m.Field != "" {
+		m._field = strings.ToLower(m.Field)
+	}
+
+	switch m._field {
+	case JWT_FIELD_EXP, JWT_FIELD_IAT, JWT_FIELD_NBF, JWT_FIELD_ISS, JWT_FIELD_SUB:
+	default:
+		return fmt.Errorf(`field "%s" not supported (exp, iat, nbf, iss, sub)`, m.Field)
+	}
+
+	return nil
+}
+
+// convertJwt decodes the JWT (plain or base64 encoded, eg. Secret data) without verification
+// and returns the configured claim, missing claims result in an empty value.
+// errors never contain the token itself
+func (m *ConfigMetricConvert) convertJwt(val string) (string, error) {
+	token := strings.TrimSpace(val)
+	if strings.Count(token, ".") != 2 {
+		decoded, err := base64.StdEncoding.DecodeString(token)
 		if err != nil {
-			panic(fmt.Errorf("unable to parse template: %w", err))
+			return "", fmt.Errorf(`invalid token format`)
 		}
+		token = strings.TrimSpace(string(decoded))
+	}
 
-		buf := new(bytes.Buffer)
-		err = tmpl.Execute(buf, ret)
-		if err != nil {
-			panic(fmt.Errorf("unable to execute template: %w", err))
+	token = strings.TrimPrefix(token, "Bearer ")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf(`invalid token format`)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", fmt.Errorf(`unable to decode token payload`)
+	}
+
+	claims := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return "", fmt.Errorf(`unable to parse token claims`)
+	}
+
+	switch claim := claims[m._field].(type) {
+	case json.Number:
+		return claim.String(), nil
+	case string:
+		return claim, nil
+	}
+
+	return "", nil
+}
+
+// parseQuantity parses Kubernetes quantities (eg. 512Mi, 250m) into base units
+func parseQuantity(val string) (float64, bool) {
+	quantity, err := resource.ParseQuantity(strings.TrimSpace(val))
+	if err != nil {
+		return 0, false
+	}
+
+	return quantity.AsApproximateFloat64(), true
+}
+
+// parseDurationSeconds parses Go (eg. 1h30m) and ISO-8601 (eg. PT1H30M) durations into seconds
+func parseDurationSeconds(val string) (float64, bool) {
+	val = strings.TrimSpace(val)
+
+	if seconds, err := strconv.ParseFloat(val, 64); err == nil {
+		return seconds, true
+	}
+
+	if duration, err := time.ParseDuration(val); err == nil {
+		return duration.Seconds(), true
+	}
+
+	match := iso8601DurationRegexp.FindStringSubmatch(strings.ToUpper(val))
+	if match == nil || strings.HasSuffix(match[0], "T") || strings.TrimPrefix(match[0], "-") == "P" {
+		return 0, false
+	}
+
+	seconds := float64(0)
+	for num, unit := range iso8601DurationUnits {
+		if part := match[num+2]; part != "" {
+			partVal, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return 0, false
+			}
+			seconds += partVal * unit
 		}
+	}
 
-		ret = buf.String()
+	if match[1] == "-" {
+		seconds = -seconds
 	}
 
-	return
+	return seconds, true
+}
+
+// parseBytes parses byte sizes (eg. 1.5GB, 512 MiB, 1Gi) into bytes
+func parseBytes(val string) (float64, bool) {
+	val = strings.TrimSpace(val)
+
+	match := byteSizeRegexp.FindStringSubmatch(val)
+	if match == nil {
+		// maybe quantity with exponent (eg. 1e3)
+		return parseQuantity(val)
+	}
+
+	size, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+
+	unit, exists := byteSizeUnits[strings.ToLower(match[2])]
+	if !exists {
+		return 0, false
+	}
+
+	return math.Round(size * unit), true
+}
+
+// formatConvertedNumber formats numeric conversion results for labels, failed conversions result in empty labels
+func formatConvertedNumber(val float64, ok bool) string {
+	if !ok {
+		return ""
+	}
+
+	return strconv.FormatFloat(val, 'f', -1, 64)
 }
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	IMAGE_FIELD_REGISTRY   = "registry"
+	IMAGE_FIELD_REPOSITORY = "repository"
+	IMAGE_FIELD_TAG        = "tag"
+	IMAGE_FIELD_DIGEST     = "digest"
+	IMAGE_FIELD_NORMALIZED = "normalized"
+
+	IMAGE_DEFAULT_REGISTRY  = "docker.io"
+	IMAGE_DEFAULT_NAMESPACE = "library"
+	IMAGE_DEFAULT_TAG       = "latest"
+)
+
+var (
+	// docker reference grammar (github.com/distribution/reference)
+	imagePathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]+)[a-z0-9]+)*$`)
+	imageDomainRegexp        = regexp.MustCompile(`^(?:(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*|\[[a-fA-F0-9:]+\])(?::[0-9]+)?$`)
+	imageTagRegexp           = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	imageDigestRegexp        = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9A-Fa-f]{32,}$`)
+)
+
+type (
+	imageReference struct {
+		registry   string
+		repository string
+		tag        string
+		digest     string
+	}
+)
+
+func (m *ConfigMetricConvert) compileImage() error {
+	m._field = IMAGE_FIELD_NORMALIZED
+	if m.Field != "" {
+		m._field = strings.ToLower(m.Field)
+	}
+
+	switch m._field {
+	case IMAGE_FIELD_REGISTRY, IMAGE_FIELD_REPOSITORY, IMAGE_FIELD_TAG, IMAGE_FIELD_DIGEST, IMAGE_FIELD_NORMALIZED:
+	default:
+		return fmt.Errorf(`field "%s" not supported (registry, repository, tag, digest, normalized)`, m.Field)
+	}
+
+	return nil
+}
+
+// convertImage parses the image reference and returns the configured component
+func (m *ConfigMetricConvert) convertImage(val string) (string, error) {
+	ref, err := parseImageReference(val)
+	if err != nil {
+		return "", err
+	}
+
+	switch m._field {
+	case IMAGE_FIELD_REGISTRY:
+		return ref.registry, nil
+	case IMAGE_FIELD_REPOSITORY:
+		return ref.repository, nil
+	case IMAGE_FIELD_TAG:
+		return ref.tag, nil
+	case IMAGE_FIELD_DIGEST:
+		return ref.digest, nil
+	}
+
+	return ref.String(), nil
+}
+
+// parseImageReference parses the image reference (name[:tag][@digest]) and normalizes it like docker,
+// (eg. nginx -> docker.io/library/nginx:latest)
+func parseImageReference(val string) (*imageReference, error) {
+	ref := &imageReference{}
+	name := strings.TrimSpace(val)
+	if name == "" {
+		return nil, fmt.Errorf(`empty image reference`)
+	}
+
+	if pos := strings.Index(name, "@"); pos >= 0 {
+		ref.digest = name[pos+1:]
+		name = name[:pos]
+		if !imageDigestRegexp.MatchString(ref.digest) {
+			return nil, fmt.Errorf(`invalid digest in image reference "%s"`, val)
+		}
+	}
+
+	// tag is separated by the last colon after the last slash (colons before can be registry ports)
+	if pos := strings.LastIndex(name, ":"); pos >= 0 && !strings.Contains(name[pos:], "/") {
+		ref.tag = name[pos+1:]
+		name = name[:pos]
+		if !imageTagRegexp.MatchString(ref.tag) {
+			return nil, fmt.Errorf(`invalid tag in image reference "%s"`, val)
+		}
+	}
+
+	// registry is only detected if the first component looks like a hostname
+	ref.registry = IMAGE_DEFAULT_REGISTRY
+	ref.repository = name
+	if pos := strings.Index(name, "/"); pos >= 0 {
+		domain := name[:pos]
+		if strings.ContainsAny(domain, ".:") || domain == "localhost" || strings.ToLower(domain) != domain {
+			ref.registry = domain
+			ref.repository = name[pos+1:]
+		}
+	}
+
+	if !imageDomainRegexp.MatchString(ref.registry) {
+		return nil, fmt.Errorf(`invalid registry in image reference "%s"`, val)
+	}
+
+	if ref.registry == "index.docker.io" {
+		ref.registry = IMAGE_DEFAULT_REGISTRY
+	}
+
+	if ref.registry == IMAGE_DEFAULT_REGISTRY && !strings.Contains(ref.repository, "/") {
+		ref.repository = IMAGE_DEFAULT_NAMESPACE + "/" + ref.repository
+	}
+
+	for _, component := range strings.Split(ref.repository, "/") {
+		if !imagePathComponentRegexp.MatchString(component) {
+			return nil, fmt.Errorf(`invalid repository in image reference "%s"`, val)
+		}
+	}
+
+	if ref.tag == "" && ref.digest == "" {
+		ref.tag = IMAGE_DEFAULT_TAG
+	}
+
+	return ref, nil
+}
+
+// String returns the normalized image reference
+func (r *imageReference) String() string {
+	ret := r.registry + "/" + r.repository
+	if r.tag != "" {
+		ret += ":" + r.tag
+	}
+	if r.digest != "" {
+		ret += "@" + r.digest
+	}
+
+	return ret
+}
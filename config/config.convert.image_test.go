@@ -0,0 +1,118 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParseImageReference(t *testing.T) {
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		val        string
+		registry   string
+		repository string
+		tag        string
+		digest     string
+		normalized string
+		error      bool
+	}{
+		{
+			val:        "nginx",
+			registry:   "docker.io",
+			repository: "library/nginx",
+			tag:        "latest",
+			normalized: "docker.io/library/nginx:latest",
+		},
+		{
+			val:        "nginx:1.25",
+			registry:   "docker.io",
+			repository: "library/nginx",
+			tag:        "1.25",
+			normalized: "docker.io/library/nginx:1.25",
+		},
+		{
+			val:        "bitnami/redis:7.2",
+			registry:   "docker.io",
+			repository: "bitnami/redis",
+			tag:        "7.2",
+			normalized: "docker.io/bitnami/redis:7.2",
+		},
+		{
+			val:        "index.docker.io/bitnami/redis",
+			registry:   "docker.io",
+			repository: "bitnami/redis",
+			tag:        "latest",
+			normalized: "docker.io/bitnami/redis:latest",
+		},
+		{
+			val:        "ghcr.io/webdevops/kube-resource-exporter:v1.0.0",
+			registry:   "ghcr.io",
+			repository: "webdevops/kube-resource-exporter",
+			tag:        "v1.0.0",
+			normalized: "ghcr.io/webdevops/kube-resource-exporter:v1.0.0",
+		},
+		{
+			val:        "localhost:5000/app",
+			registry:   "localhost:5000",
+			repository: "app",
+			tag:        "latest",
+			normalized: "localhost:5000/app:latest",
+		},
+		{
+			val:        "registry.example.com:443/team/app:1.0@" + digest,
+			registry:   "registry.example.com:443",
+			repository: "team/app",
+			tag:        "1.0",
+			digest:     digest,
+			normalized: "registry.example.com:443/team/app:1.0@" + digest,
+		},
+		{
+			val:        "app@" + digest,
+			registry:   "docker.io",
+			repository: "library/app",
+			digest:     digest,
+			normalized: "docker.io/library/app@" + digest,
+		},
+		{val: "", error: true},
+		{val: "Nginx", error: true},
+		{val: "nginx:-invalid", error: true},
+		{val: "nginx@sha256:123", error: true},
+		{val: "registry.example.com/App", error: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.val, func(t *testing.T) {
+			ref, err := parseImageReference(test.val)
+			if test.error {
+				if err == nil {
+					t.Fatalf("expected error, got %q", ref.String())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ref.registry != test.registry {
+				t.Errorf("expected registry %q, got %q", test.registry, ref.registry)
+			}
+
+			if ref.repository != test.repository {
+				t.Errorf("expected repository %q, got %q", test.repository, ref.repository)
+			}
+
+			if ref.tag != test.tag {
+				t.Errorf("expected tag %q, got %q", test.tag, ref.tag)
+			}
+
+			if ref.digest != test.digest {
+				t.Errorf("expected digest %q, got %q", test.digest, ref.digest)
+			}
+
+			if ref.String() != test.normalized {
+				t.Errorf("expected normalized %q, got %q", test.normalized, ref.String())
+			}
+		})
+	}
+}
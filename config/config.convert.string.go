@@ -0,0 +1,112 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+)
+
+var (
+	// conversions which transform strings, usable for labels and values
+	stringConversions = map[string]bool{
+		CONVERT_BASE64DECODE: true,
+		CONVERT_REGEXEXTRACT: true,
+		CONVERT_REGEXREPLACE: true,
+		CONVERT_TRUNCATE:     true,
+		CONVERT_SHA256:       true,
+		CONVERT_MAP:          true,
+		CONVERT_SEMVER:       true,
+	}
+)
+
+func (m *ConfigMetricConvert) compileString() error {
+	switch m._method {
+	case CONVERT_REGEXEXTRACT, CONVERT_REGEXREPLACE:
+		if m.Regex == "" {
+			return fmt.Errorf(`regex is required`)
+		}
+
+		regex, err := regexp.Compile(m.Regex)
+		if err != nil {
+			return err
+		}
+		m._regex = regex
+
+		if m._method == CONVERT_REGEXEXTRACT {
+			// first capture group by default, whole match if regex has no groups
+			m._group = min(1, regex.NumSubexp())
+			if m.Group != nil {
+				m._group = *m.Group
+			}
+
+			if m._group < 0 || m._group > regex.NumSubexp() {
+				return fmt.Errorf(`group %d not found in regex "%s"`, m._group, m.Regex)
+			}
+		}
+
+	case CONVERT_TRUNCATE:
+		if m.Length <= 0 {
+			return fmt.Errorf(`length must be greater than zero`)
+		}
+
+	case CONVERT_MAP:
+		if len(m.Map) == 0 {
+			return fmt.Errorf(`map is required`)
+		}
+
+	case CONVERT_SEMVER:
+		return m.compileSemver()
+	}
+
+	return nil
+}
+
+// convertString applies the string conversion, returns false if the conversion failed
+func (m *ConfigMetricConvert) convertString(val string) (string, bool) {
+	switch m._method {
+	case CONVERT_BASE64DECODE:
+		for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+			if decoded, err := encoding.DecodeString(val); err == nil {
+				return string(decoded), true
+			}
+		}
+		return "", false
+
+	case CONVERT_REGEXEXTRACT:
+		match := m._regex.FindStringSubmatch(val)
+		if match == nil {
+			return "", false
+		}
+		return match[m._group], true
+
+	case CONVERT_REGEXREPLACE:
+		return m._regex.ReplaceAllString(val, m.Replacement), true
+
+	case CONVERT_TRUNCATE:
+		if runes := []rune(val); len(runes) > m.Length {
+			return string(runes[:m.Length]), true
+		}
+		return val, true
+
+	case CONVERT_SHA256:
+		hash := sha256.Sum256([]byte(val))
+		return hex.EncodeToString(hash[:]), true
+
+	case CONVERT_MAP:
+		if mapped, exists := m.Map[val]; exists {
+			return mapped, true
+		}
+
+		if m.Default != nil {
+			return *m.Default, true
+		}
+		return val, true
+
+	case CONVERT_SEMVER:
+		return m.convertSemver(val)
+	}
+
+	return val, true
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestConvertString(t *testing.T) {
+	tests := []struct {
+		name    string
+		convert []*ConfigMetricConvert
+		val     string
+		label   string
+		value   *float64
+	}{
+		{
+			name:    "base64decode",
+			convert: []*ConfigMetricConvert{{Method: "base64Decode"}},
+			val:     base64.StdEncoding.EncodeToString([]byte("42")),
+			label:   "42",
+			value:   floatPtr(42),
+		},
+		{
+			name:    "base64decode raw url",
+			convert: []*ConfigMetricConvert{{Method: "base64Decode"}},
+			val:     base64.RawURLEncoding.EncodeToString([]byte("app?")),
+			label:   "app?",
+		},
+		{
+			name:    "base64decode invalid",
+			convert: []*ConfigMetricConvert{{Method: "base64Decode"}},
+			val:     "!!!",
+			label:   "",
+		},
+		{
+			name:    "regexExtract",
+			convert: []*ConfigMetricConvert{{Method: "regexExtract", Regex: `^v([0-9]+)\.([0-9]+)`}},
+			val:     "v1.28.3",
+			label:   "1",
+			value:   floatPtr(1),
+		},
+		{
+			name:    "regexExtract group",
+			convert: []*ConfigMetricConvert{{Method: "regexExtract", Regex: `^v([0-9]+)\.([0-9]+)`, Group: intPtr(2)}},
+			val:     "v1.28.3",
+			label:   "28",
+			value:   floatPtr(28),
+		},
+		{
+			name:    "regexExtract whole match",
+			convert: []*ConfigMetricConvert{{Method: "regexExtract", Regex: `[0-9]+\.[0-9]+`, Group: intPtr(0)}},
+			val:     "v1.28.3",
+			label:   "1.28",
+			value:   floatPtr(1.28),
+		},
+		{
+			name:    "regexExtract no match",
+			convert: []*ConfigMetricConvert{{Method: "regexExtract", Regex: `^v([0-9]+)`}},
+			val:     "latest",
+			label:   "",
+		},
+		{
+			name:    "regexReplace",
+			convert: []*ConfigMetricConvert{{Method: "regexReplace", Regex: `[^0-9]`, Replacement: ""}},
+			val:     "12 replicas",
+			label:   "12",
+			value:   floatPtr(12),
+		},
+		{
+			name:    "regexReplace group reference",
+			convert: []*ConfigMetricConvert{{Method: "regexReplace", Regex: `^([a-z]+)-([a-z]+)$`, Replacement: "$2/$1"}},
+			val:     "app-web",
+			label:   "web/app",
+		},
+		{
+			name:    "truncate",
+			convert: []*ConfigMetricConvert{{Method: "truncate", Length: 3}},
+			val:     "12345",
+			label:   "123",
+			value:   floatPtr(123),
+		},
+		{
+			name:    "truncate runes",
+			convert: []*ConfigMetricConvert{{Method: "truncate", Length: 2}},
+			val:     "äöü",
+			label:   "äö",
+		},
+		{
+			name:    "truncate short",
+			convert: []*ConfigMetricConvert{{Method: "truncate", Length: 10}},
+			val:     "app",
+			label:   "app",
+		},
+		{
+			name:    "sha256",
+			convert: []*ConfigMetricConvert{{Method: "sha256"}},
+			val:     "app",
+			label:   "a172cedcae47474b615c54d510a5d84a8dea3032e958587430b413538be3f333",
+		},
+		{
+			name:    "map",
+			convert: []*ConfigMetricConvert{{Method: "map", Map: map[string]string{"Running": "1", "Pending": "2"}}},
+			val:     "Pending",
+			label:   "2",
+			value:   floatPtr(2),
+		},
+		{
+			name:    "map unmapped",
+			convert: []*ConfigMetricConvert{{Method: "map", Map: map[string]string{"Running": "1"}}},
+			val:     "Failed",
+			label:   "Failed",
+		},
+		{
+			name:    "map default",
+			convert: []*ConfigMetricConvert{{Method: "map", Map: map[string]string{"Running": "1"}, Default: stringPtr("0")}},
+			val:     "Failed",
+			label:   "0",
+			value:   floatPtr(0),
+		},
+		{
+			name: "chain",
+			convert: []*ConfigMetricConvert{
+				{Method: "base64Decode"},
+				{Method: "regexExtract", Regex: `replicas=([0-9]+)`},
+			},
+			val:   base64.StdEncoding.EncodeToString([]byte("replicas=5")),
+			label: "5",
+			value: floatPtr(5),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// label path
+			labelConfig := &ConfigMetricJsonPath{Convert: test.convert}
+			if err := labelConfig.compileConvert(labelConversions); err != nil {
+				t.Fatal(err)
+			}
+
+			label, err := labelConfig.DoConvertLabel(test.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if label != test.label {
+				t.Errorf("expected label %q, got %q", test.label, label)
+			}
+
+			// value path
+			valueConfig := &ConfigMetricJsonPath{Convert: test.convert}
+			if err := valueConfig.compileConvert(valueConversions); err != nil {
+				t.Fatal(err)
+			}
+
+			value, err := valueConfig.DoConvertValue(test.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			switch {
+			case test.value == nil && value != nil:
+				t.Errorf("expected no value, got %v", *value)
+			case test.value != nil && value == nil:
+				t.Errorf("expected value %v, got no value", *test.value)
+			case test.value != nil && *value != *test.value:
+				t.Errorf("expected value %v, got %v", *test.value, *value)
+			}
+		})
+	}
+}
+
+func TestConvertStringCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		convert *ConfigMetricConvert
+		error   string
+	}{
+		{name: "regex missing", convert: &ConfigMetricConvert{Method: "regexExtract"}, error: `regex is required`},
+		{name: "regex invalid", convert: &ConfigMetricConvert{Method: "regexReplace", Regex: `(`}, error: `missing closing )`},
+		{name: "group zero", convert: &ConfigMetricConvert{Method: "regexExtract", Regex: `(a)`, Group: intPtr(0)}},
+		{name: "group not found", convert: &ConfigMetricConvert{Method: "regexExtract", Regex: `(a)`, Group: intPtr(2)}, error: `group 2 not found in regex "(a)"`},
+		{name: "truncate length", convert: &ConfigMetricConvert{Method: "truncate"}, error: `length must be greater than zero`},
+		{name: "map empty", convert: &ConfigMetricConvert{Method: "map"}, error: `map is required`},
+		{name: "parameter", convert: &ConfigMetricConvert{Method: "sha256", Length: 3}, error: `parameter "length" is not supported`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.convert.compile()
+			switch {
+			case test.error == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q", test.error)
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			}
+		})
+	}
+}
+
+func intPtr(val int) *int {
+	return &val
+}
+
+func stringPtr(val string) *string {
+	return &val
+}
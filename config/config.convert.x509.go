@@ -0,0 +1,154 @@
+package config
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	X509_FIELD_NOTAFTER  = "notafter"
+	X509_FIELD_NOTBEFORE = "notbefore"
+	X509_FIELD_SUBJECT   = "subject"
+	X509_FIELD_ISSUER    = "issuer"
+	X509_FIELD_SANS      = "sans"
+	X509_FIELD_SERIAL    = "serial"
+
+	X509_SELECT_LEAF           = "leaf"
+	X509_SELECT_EARLIESTEXPIRY = "earliestexpiry"
+)
+
+var (
+	x509Fields = map[string]bool{
+		X509_FIELD_NOTAFTER:  true,
+		X509_FIELD_NOTBEFORE: true,
+		X509_FIELD_SUBJECT:   true,
+		X509_FIELD_ISSUER:    true,
+		X509_FIELD_SANS:      true,
+		X509_FIELD_SERIAL:    true,
+	}
+)
+
+func (m *ConfigMetricConvert) compileX509() error {
+	m._field = X509_FIELD_NOTAFTER
+	if m.Field != "" {
+		m._field = strings.ToLower(m.Field)
+	}
+
+	if !x509Fields[m._field] {
+		return fmt.Errorf(`field "%s" not supported (notAfter, notBefore, subject, issuer, sans, serial)`, m.Field)
+	}
+
+	m._select = X509_SELECT_LEAF
+	if m.Select != "" {
+		m._select = strings.ToLower(m.Select)
+	}
+
+	switch m._select {
+	case X509_SELECT_LEAF, X509_SELECT_EARLIESTEXPIRY:
+	default:
+		return fmt.Errorf(`select "%s" not supported (leaf, earliestExpiry)`, m.Select)
+	}
+
+	return nil
+}
+
+// convertX509 parses the certificate (chain) and returns the configured field of the selected certificate,
+// timestamps are returned as unix timestamp
+func (m *ConfigMetricConvert) convertX509(val string) (string, error) {
+	certs, err := parseCertificates(val)
+	if err != nil {
+		return "", err
+	}
+
+	cert := certs[0]
+	if m._select == X509_SELECT_EARLIESTEXPIRY {
+		for _, row := range certs[1:] {
+			if row.NotAfter.Before(cert.NotAfter) {
+				cert = row
+			}
+		}
+	}
+
+	switch m._field {
+	case X509_FIELD_NOTAFTER:
+		return strconv.FormatInt(cert.NotAfter.Unix(), 10), nil
+	case X509_FIELD_NOTBEFORE:
+		return strconv.FormatInt(cert.NotBefore.Unix(), 10), nil
+	case X509_FIELD_SUBJECT:
+		return cert.Subject.String(), nil
+	case X509_FIELD_ISSUER:
+		return cert.Issuer.String(), nil
+	case X509_FIELD_SANS:
+		sans := []string{}
+		sans = append(sans, cert.DNSNames...)
+		for _, ip := range cert.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+		sans = append(sans, cert.EmailAddresses...)
+		for _, uri := range cert.URIs {
+			sans = append(sans, uri.String())
+		}
+		return strings.Join(sans, ","), nil
+	case X509_FIELD_SERIAL:
+		return fmt.Sprintf("%x", cert.SerialNumber), nil
+	}
+
+	return "", nil
+}
+
+// parseCertificates parses PEM (plain or base64 encoded) or base64 encoded DER certificates,
+// certificates are returned in order of appearance
+func parseCertificates(val string) ([]*x509.Certificate, error) {
+	data := []byte(strings.TrimSpace(val))
+	if !strings.Contains(string(data), "-----BEGIN") {
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			return nil, fmt.Errorf(`unable to decode certificate: %w`, err)
+		}
+		data = decoded
+	}
+
+	if !strings.Contains(string(data), "-----BEGIN") {
+		// DER encoded
+		certs, err := x509.ParseCertificates(data)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to parse certificate: %w`, err)
+		}
+
+		if len(certs) == 0 {
+			return nil, fmt.Errorf(`no certificate found`)
+		}
+
+		return certs, nil
+	}
+
+	certs := []*x509.Certificate{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			// eg. private key of tls secrets
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to parse certificate: %w`, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf(`no certificate found`)
+	}
+
+	return certs, nil
+}
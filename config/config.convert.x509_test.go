@@ -0,0 +1,106 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestConvertX509(t *testing.T) {
+	leafNotAfter := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	caNotAfter := time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	leaf := testCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(0x1a2b),
+		Subject:      pkix.Name{CommonName: "app.example.com"},
+		Issuer:       pkix.Name{CommonName: "ca"},
+		NotBefore:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     leafNotAfter,
+		DNSNames:     []string{"app.example.com", "www.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.0.1")},
+	})
+	ca := testCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ca"},
+		NotBefore:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     caNotAfter,
+	})
+
+	leafPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf}))
+	caPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca}))
+	keyPem := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")}))
+	chain := leafPem + caPem
+
+	tests := []struct {
+		name       string
+		field      string
+		certSelect string
+		val        string
+		expected   string
+		error      bool
+	}{
+		{name: "notAfter", val: chain, expected: strconv.FormatInt(leafNotAfter.Unix(), 10)},
+		{name: "notBefore", field: "notBefore", val: chain, expected: strconv.FormatInt(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Unix(), 10)},
+		{name: "earliestExpiry", certSelect: "earliestExpiry", val: chain, expected: strconv.FormatInt(caNotAfter.Unix(), 10)},
+		{name: "subject", field: "subject", val: chain, expected: "CN=app.example.com"},
+		{name: "sans", field: "sans", val: chain, expected: "app.example.com,www.example.com,10.0.0.1"},
+		{name: "serial", field: "serial", val: chain, expected: "1a2b"},
+		{name: "base64 pem", val: base64.StdEncoding.EncodeToString([]byte(chain)), expected: strconv.FormatInt(leafNotAfter.Unix(), 10)},
+		{name: "base64 der", val: base64.StdEncoding.EncodeToString(leaf), expected: strconv.FormatInt(leafNotAfter.Unix(), 10)},
+		{name: "private key is skipped", val: keyPem + leafPem, expected: strconv.FormatInt(leafNotAfter.Unix(), 10)},
+		{name: "only private key", val: keyPem, error: true},
+		{name: "invalid base64", val: "certificate", error: true},
+		{name: "invalid der", val: base64.StdEncoding.EncodeToString([]byte("certificate")), error: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			convert := &ConfigMetricConvert{Method: CONVERT_X509, Field: test.field, Select: test.certSelect}
+			if err := convert.compile(); err != nil {
+				t.Fatal(err)
+			}
+
+			ret, err := convert.decode(test.val)
+			if test.error {
+				if err == nil {
+					t.Fatalf("expected error, got %q", ret)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ret != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, ret)
+			}
+		})
+	}
+}
+
+// testCertificate returns a self-signed DER encoded certificate
+func testCertificate(t *testing.T, template *x509.Certificate) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return der
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestParseDurationSeconds(t *testing.T) {
+	tests := []struct {
+		val     string
+		seconds float64
+		ok      bool
+	}{
+		{val: "90", seconds: 90, ok: true},
+		{val: "1h30m", seconds: 5400, ok: true},
+		{val: "PT1H30M", seconds: 5400, ok: true},
+		{val: "pt15s", seconds: 15, ok: true},
+		{val: "P1D", seconds: 86400, ok: true},
+		{val: "P1.5D", seconds: 129600, ok: true},
+		{val: "P1W", seconds: 604800, ok: true},
+		{val: "P1Y", seconds: 31536000, ok: true},
+		{val: "P1M", seconds: 2592000, ok: true},
+		{val: "P1DT2H", seconds: 93600, ok: true},
+		{val: "-PT5S", seconds: -5, ok: true},
+		{val: " PT1M ", seconds: 60, ok: true},
+		{val: "P", ok: false},
+		{val: "PT", ok: false},
+		{val: "P1DT", ok: false},
+		{val: "P1H", ok: false},
+		{val: "", ok: false},
+		{val: "soon", ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.val, func(t *testing.T) {
+			seconds, ok := parseDurationSeconds(test.val)
+			if ok != test.ok {
+				t.Fatalf("expected ok=%v, got %v", test.ok, ok)
+			}
+
+			if ok && seconds != test.seconds {
+				t.Errorf("expected %v seconds, got %v", test.seconds, seconds)
+			}
+		})
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		val   string
+		bytes float64
+		ok    bool
+	}{
+		{val: "100", bytes: 100, ok: true},
+		{val: "100B", bytes: 100, ok: true},
+		{val: "1.5GB", bytes: 1500000000, ok: true},
+		{val: "1.5gb", bytes: 1500000000, ok: true},
+		{val: "512 MiB", bytes: 536870912, ok: true},
+		{val: "1Gi", bytes: 1073741824, ok: true},
+		{val: "2k", bytes: 2000, ok: true},
+		{val: "1e3", bytes: 1000, ok: true},
+		{val: "1XB", ok: false},
+		{val: "large", ok: false},
+		{val: "", ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.val, func(t *testing.T) {
+			bytes, ok := parseBytes(test.val)
+			if ok != test.ok {
+				t.Fatalf("expected ok=%v, got %v", test.ok, ok)
+			}
+
+			if ok && bytes != test.bytes {
+				t.Errorf("expected %v bytes, got %v", test.bytes, bytes)
+			}
+		})
+	}
+}
+
+func TestConvertJwt(t *testing.T) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"exp":1700000000,"iat":1600000000,"iss":"https://issuer.example.com","sub":"system:serviceaccount:default:app"}`))
+	token := header + "." + payload + ".signature"
+
+	tests := []struct {
+		name     string
+		field    string
+		val      string
+		expected string
+		error    bool
+	}{
+		{name: "exp", val: token, expected: "1700000000"},
+		{name: "iat", field: "iat", val: token, expected: "1600000000"},
+		{name: "iss", field: "iss", val: token, expected: "https://issuer.example.com"},
+		{name: "sub", field: "SUB", val: token, expected: "system:serviceaccount:default:app"},
+		{name: "missing claim", field: "nbf", val: token, expected: ""},
+		{name: "base64 encoded", val: base64.StdEncoding.EncodeToString([]byte(token)), expected: "1700000000"},
+		{name: "bearer", val: "Bearer " + token, expected: "1700000000"},
+		{name: "invalid format", val: "token", error: true},
+		{name: "invalid payload", val: header + ".!!!.signature", error: true},
+		{name: "invalid claims", val: header + "." + base64.RawURLEncoding.EncodeToString([]byte("claims")) + ".signature", error: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			convert := &ConfigMetricConvert{Method: CONVERT_JWT, Field: test.field}
+			if err := convert.compile(); err != nil {
+				t.Fatal(err)
+			}
+
+			ret, err := convert.decode(test.val)
+			if test.error {
+				if err == nil {
+					t.Fatalf("expected error, got %q", ret)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ret != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, ret)
+			}
+		})
+	}
+}
+
+func TestConvertToDateTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		convert  ConfigMetricConvert
+		val      string
+		expected string
+	}{
+		{
+			name:     "epoch",
+			convert:  ConfigMetricConvert{Method: CONVERT_TODATETIME},
+			val:      "1700000000",
+			expected: "2023-11-14T22:13:20Z",
+		},
+		{
+			name:     "epoch milliseconds",
+			convert:  ConfigMetricConvert{Method: CONVERT_TODATETIME, Epoch: "ms"},
+			val:      "1700000000000",
+			expected: "2023-11-14T22:13:20Z",
+		},
+		{
+			name:     "epoch with timezone",
+			convert:  ConfigMetricConvert{Method: CONVERT_TODATETIME, Timezone: "Europe/Berlin"},
+			val:      "1700000000",
+			expected: "2023-11-14T23:13:20+01:00",
+		},
+		{
+			name:     "epoch with output timezone",
+			convert:  ConfigMetricConvert{Method: CONVERT_TODATETIME, Timezone: "Europe/Berlin", OutputTimezone: "UTC"},
+			val:      "1700000000",
+			expected: "2023-11-14T22:13:20Z",
+		},
+		{
+			name:     "datetime keeps zone",
+			convert:  ConfigMetricConvert{Method: CONVERT_TODATETIME},
+			val:      "2023-11-14T23:13:20+01:00",
+			expected: "2023-11-14T23:13:20+01:00",
+		},
+		{
+			name:     "layout with timezone",
+			convert:  ConfigMetricConvert{Method: CONVERT_TODATETIME, Layouts: []string{"2006-01-02 15:04"}, Timezone: "Europe/Berlin", OutputLayout: "2006-01-02 15:04:05", OutputTimezone: "UTC"},
+			val:      "2023-11-14 23:13",
+			expected: "2023-11-14 22:13:00",
+		},
+		{
+			name:     "invalid",
+			convert:  ConfigMetricConvert{Method: CONVERT_TODATETIME},
+			val:      "tomorrow",
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &ConfigMetricJsonPath{Convert: []*ConfigMetricConvert{&test.convert}}
+			if err := config.compileConvert(labelConversions); err != nil {
+				t.Fatal(err)
+			}
+
+			ret, err := config.DoConvertLabel(test.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ret != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, ret)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	yaml "github.com/goccy/go-yaml"
+	k8sjson "k8s.io/apimachinery/pkg/util/json"
+)
+
+const (
+	DECODE_HELMRELEASE = "helmrelease"
+
+	PARSE_JSON = "json"
+	PARSE_YAML = "yaml"
+
+	// DECODE_MAX_SIZE limits the size of decompressed documents (protection against gzip bombs)
+	DECODE_MAX_SIZE = 32 * 1024 * 1024
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+type (
+	// documentCache caches the decoded documents of an object while its metrics are collected,
+	// the same field is usually used by multiple labels and values
+	documentCache struct {
+		lock      sync.Mutex
+		documents map[documentCacheKey]interface{}
+	}
+
+	documentCacheKey struct {
+		decode string
+		raw    string
+	}
+)
+
+func newDocumentCache() *documentCache {
+	return &documentCache{
+		documents: map[documentCacheKey]interface{}{},
+	}
+}
+
+func (m *ConfigMetricJsonPath) compileDecode() error {
+	switch {
+	case m.Decode != "" && m.Parse != "":
+		return fmt.Errorf(`only one of decode or parse can be used`)
+
+	case m.Decode != "":
+		m._decode = strings.ToLower(m.Decode)
+		m._decodeName = m.Decode
+		if m._decode != DECODE_HELMRELEASE {
+			return fmt.Errorf(`decode "%s" not supported`, m.Decode)
+		}
+
+	case m.Parse != "":
+		m._decode = strings.ToLower(m.Parse)
+		m._decodeName = m.Parse
+		if m._decode != PARSE_JSON && m._decode != PARSE_YAML {
+			return fmt.Errorf(`parse "%s" not supported (json, yaml)`, m.Parse)
+		}
+
+	default:
+		if m.DocumentPath != "" {
+			return fmt.Errorf(`documentPath can only be used with decode or parse`)
+		}
+		return nil
+	}
+
+	if m.Path == "" && m.Jq == "" {
+		return fmt.Errorf(`"%s" requires jsonPath or jq`, m._decodeName)
+	}
+
+	// whole document if not set
+	path, err := compileObjectPath(m.DocumentPath)
+	if err != nil {
+		return err
+	}
+	m._documentPath = path
+
+	return nil
+}
+
+// findDocumentResults decodes (or parses) the results into documents and returns the results of documentPath
+func (m *ConfigMetricJsonPath) findDocumentResults(element ObjectElement, results []interface{}) ([]interface{}, error) {
+	ret := []interface{}{}
+	for _, result := range results {
+		raw, ok := result.(string)
+		if !ok || raw == "" {
+			continue
+		}
+
+		document, err := m.decodeDocument(element.documents, raw)
+		if err != nil {
+			return nil, &ConvertError{Method: m._decodeName, Err: err}
+		}
+
+		documentResults, err := m._documentPath.FindResults(ObjectElement{
+			Root:      element.Root,
+			Value:     document,
+			jqRoot:    element.jqRoot,
+			documents: element.documents,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, documentResults...)
+	}
+
+	return ret, nil
+}
+
+// decodeDocument decodes (or parses) the raw value, documents are cached in cache (if set)
+func (m *ConfigMetricJsonPath) decodeDocument(cache *documentCache, raw string) (interface{}, error) {
+	cacheKey := documentCacheKey{decode: m._decode, raw: raw}
+	if cache != nil {
+		cache.lock.Lock()
+		defer cache.lock.Unlock()
+
+		if document, exists := cache.documents[cacheKey]; exists {
+			return document, nil
+		}
+	}
+
+	var document interface{}
+	var err error
+	switch m._decode {
+	case DECODE_HELMRELEASE:
+		document, err = decodeHelmRelease(raw)
+	case PARSE_JSON:
+		document, err = parseJsonDocument([]byte(raw))
+	case PARSE_YAML:
+		document, err = parseYamlDocument([]byte(raw))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cache != nil {
+		cache.documents[cacheKey] = document
+	}
+
+	return document, nil
+}
+
+// decodeHelmRelease decodes Helm 3 releases (base64 and gzip encoded JSON),
+// supports the Secret field (additional base64 encoding) and the ConfigMap field
+func decodeHelmRelease(raw string) (interface{}, error) {
+	data := []byte(strings.TrimSpace(raw))
+
+	// Secret data is base64 encoded twice, ConfigMap data once
+	for i := 0; i < 2 && !bytes.HasPrefix(data, gzipMagic); i++ {
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			return nil, fmt.Errorf(`unable to decode release: %w`, err)
+		}
+		data = decoded
+	}
+
+	if bytes.HasPrefix(data, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf(`unable to decompress release: %w`, err)
+		}
+		defer reader.Close() // nolint:errcheck
+
+		data, err = io.ReadAll(io.LimitReader(reader, DECODE_MAX_SIZE+1))
+		if err != nil {
+			return nil, fmt.Errorf(`unable to decompress release: %w`, err)
+		}
+
+		if len(data) > DECODE_MAX_SIZE {
+			return nil, fmt.Errorf(`decompressed release exceeds maximum size of %d bytes`, DECODE_MAX_SIZE)
+		}
+	}
+
+	return parseJsonDocument(data)
+}
+
+// parseJsonDocument parses JSON, numbers are decoded as int64 or float64 like Kubernetes objects
+func parseJsonDocument(data []byte) (interface{}, error) {
+	var document interface{}
+	if err := k8sjson.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf(`unable to parse JSON: %w`, err)
+	}
+
+	return document, nil
+}
+
+// parseYamlDocument parses YAML (first document), values are converted like JSON documents
+func parseYamlDocument(data []byte) (interface{}, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to parse YAML: %w`, err)
+	}
+
+	return parseJsonDocument(jsonData)
+}
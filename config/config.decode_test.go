@@ -0,0 +1,102 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestDecodeDocument(t *testing.T) {
+	release := `{"name":"app","version":3,"info":{"status":"deployed"}}`
+
+	buf := new(bytes.Buffer)
+	writer := gzip.NewWriter(buf)
+	if _, err := writer.Write([]byte(release)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	configMapData := base64.StdEncoding.EncodeToString(buf.Bytes())
+	secretData := base64.StdEncoding.EncodeToString([]byte(configMapData))
+
+	tests := []struct {
+		name         string
+		decode       string
+		parse        string
+		documentPath string
+		val          string
+		expected     interface{}
+		error        bool
+	}{
+		{name: "helm release secret", decode: "helmRelease", documentPath: ".info.status", val: secretData, expected: "deployed"},
+		{name: "helm release configmap", decode: "helmRelease", documentPath: ".version", val: configMapData, expected: int64(3)},
+		{name: "json", parse: "json", documentPath: ".name", val: release, expected: "app"},
+		{name: "yaml", parse: "yaml", documentPath: ".spec.replicas", val: "spec:\n  replicas: 2\n", expected: int64(2)},
+		{name: "invalid helm release", decode: "helmRelease", val: "release", error: true},
+		{name: "invalid json", parse: "json", val: "{", error: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &ConfigMetricJsonPath{Path: ".data.release", Decode: test.decode, Parse: test.parse, DocumentPath: test.documentPath}
+			if err := config.compile(); err != nil {
+				t.Fatal(err)
+			}
+
+			element := NewObjectElement(map[string]interface{}{
+				"data": map[string]interface{}{"release": test.val},
+			})
+
+			// second lookup is served from the document cache of the object
+			for i := 0; i < 2; i++ {
+				results, err := config.FindResults(element)
+				if test.error {
+					if err == nil {
+						t.Fatalf("expected error, got %v", results)
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(results) != 1 || results[0] != test.expected {
+					t.Errorf("expected %v, got %v", test.expected, results)
+				}
+			}
+
+			if len(element.documents.documents) != 1 {
+				t.Errorf("expected one cached document, got %d", len(element.documents.documents))
+			}
+		})
+	}
+}
+
+func TestDecodeHelmReleaseMaxSize(t *testing.T) {
+	buf := new(bytes.Buffer)
+	writer := gzip.NewWriter(buf)
+	if _, err := writer.Write(make([]byte, DECODE_MAX_SIZE+1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ConfigMetricJsonPath{Path: ".data.release", Decode: "helmRelease"}
+	if err := config.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := config.FindResults(NewObjectElement(map[string]interface{}{
+		"data": map[string]interface{}{"release": base64.StdEncoding.EncodeToString(buf.Bytes())},
+	}))
+
+	var convertErr *ConvertError
+	if !errors.As(err, &convertErr) {
+		t.Fatalf("expected conversion error, got %v", err)
+	}
+}
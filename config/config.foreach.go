@@ -0,0 +1,441 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/kubectl/pkg/cmd/get"
+)
+
+const (
+	// OBJECTPATH_ROOT prefixes paths which should be evaluated against the object instead of the current element
+	OBJECTPATH_ROOT = "$"
+
+	// OBJECTPATH_SELF references the current element itself
+	OBJECTPATH_SELF = "@"
+)
+
+type (
+	ConfigMetricForeach struct {
+		Path     string `yaml:"jsonPath" json:"jsonPath"`
+		_path    *objectPath
+		_index   *foreachIndex
+		KeyLabel string `yaml:"keyLabel"`
+	}
+
+	// foreachIndex finds the elements of a path which expands an array (eg. by filter or slice)
+	// together with their index in the source array, the index is used as element key
+	foreachIndex struct {
+		// path of the source array
+		array *objectPath
+
+		// array selector expanding the array, eg. [?(@.name=="app")] or [1:]
+		selector *jsonpath.JSONPath
+		filter   bool
+
+		// path of the results relative to the selected array items
+		suffix *objectPath
+	}
+
+	// ObjectElement is the part of an object a metric is evaluated against,
+	// without foreach the element is the object itself
+	ObjectElement struct {
+		// Root is the whole object
+		Root map[string]interface{}
+
+		// Value is the current element
+		Value interface{}
+
+		// Key is the index or map key of the current element (only set for foreach)
+		Key *string
+
+		// jq normalized versions of root and value, shared by all copies of the element
+		jqRoot  *jqValue
+		jqValue *jqValue
+
+		// decoded documents of the object, shared by all elements of the object
+		documents *documentCache
+	}
+
+	// objectPath is a compiled jsonPath which is evaluated against the current element
+	// or, if prefixed with "$", against the root object
+	objectPath struct {
+		path *jsonpath.JSONPath
+		root bool
+
+		// path expands into multiple results (wildcard, slice, filter, union or recursive descent)
+		multi bool
+	}
+)
+
+// NewObjectElement returns the element for the whole object,
+// the element should be reused for all metrics of the object (see Elements)
+func NewObjectElement(object map[string]interface{}) ObjectElement {
+	root := newJqValue(object)
+	return ObjectElement{
+		Root:      object,
+		Value:     object,
+		jqRoot:    root,
+		jqValue:   root,
+		documents: newDocumentCache(),
+	}
+}
+
+func (m *ConfigMetricForeach) Compile() error {
+	if m.Path == "" {
+		return fmt.Errorf(`jsonPath must be set for foreach`)
+	}
+
+	path, err := compileObjectPath(m.Path)
+	if err != nil {
+		return err
+	}
+	m._path = path
+
+	if path.multi {
+		index, err := compileForeachIndex(m.Path)
+		if err != nil {
+			return err
+		}
+		m._index = index
+	}
+
+	if m.KeyLabel != "" && !labelNameRegexp.MatchString(m.KeyLabel) {
+		return fmt.Errorf(`keyLabel "%s" is not a valid Prometheus label name`, m.KeyLabel)
+	}
+
+	return nil
+}
+
+// Elements returns the elements the metric should be evaluated against,
+// without foreach this is only the object element itself
+func (m *ConfigMetric) Elements(objectElement ObjectElement) ([]ObjectElement, error) {
+	if m.Foreach == nil {
+		return []ObjectElement{objectElement}, nil
+	}
+
+	object := objectElement.Root
+
+	// elements of an expanded array are keyed by their index in the source array (not by their position in the results)
+	var keys []string
+	var results []interface{}
+	var err error
+	if m.Foreach._index != nil {
+		keys, results, err = m.Foreach._index.FindResults(objectElement)
+	} else {
+		results, err = m.Foreach._path.FindResults(objectElement)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// path selects a single array or map, iterate over its items
+	// (not if the path expanded into one result, eg. [*] matching only one item)
+	if len(results) == 1 && !m.Foreach._path.multi {
+		switch v := results[0].(type) {
+		case []interface{}:
+			results = v
+		case map[string]interface{}:
+			keys := []string{}
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			ret := []ObjectElement{}
+			for _, key := range keys {
+				ret = append(ret, ObjectElement{
+					Root:      object,
+					Value:     v[key],
+					Key:       &key,
+					jqRoot:    objectElement.jqRoot,
+					jqValue:   newJqValue(v[key]),
+					documents: objectElement.documents,
+				})
+			}
+			return ret, nil
+		}
+	}
+
+	ret := []ObjectElement{}
+	for num, val := range results {
+		key := strconv.Itoa(num)
+		if keys != nil {
+			key = keys[num]
+		}
+		ret = append(ret, ObjectElement{
+			Root:      object,
+			Value:     val,
+			Key:       &key,
+			jqRoot:    objectElement.jqRoot,
+			jqValue:   newJqValue(val),
+			documents: objectElement.documents,
+		})
+	}
+
+	return ret, nil
+}
+
+// KeyLabels returns the labels of the element, eg. the foreach index or map key
+func (m *ConfigMetric) KeyLabels(element ObjectElement) map[string]string {
+	ret := map[string]string{}
+	if m.Foreach != nil && m.Foreach.KeyLabel != "" && element.Key != nil {
+		ret[m.Foreach.KeyLabel] = *element.Key
+	}
+
+	return ret
+}
+
+// compileForeachIndex splits the path at the first array selector expanding the results,
+// returns nil if the path is expanded otherwise (eg. recursive descent, map wildcard or nested expansions)
+func compileForeachIndex(path string) (*foreachIndex, error) {
+	path, root := trimObjectPathRoot(path)
+
+	jsonPathString, err := get.RelaxedJSONPathExpression(path)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to build JSONpath "%s": %w`, jsonPathString, err)
+	}
+	if !strings.HasPrefix(jsonPathString, "{") || !strings.HasSuffix(jsonPathString, "}") {
+		return nil, nil
+	}
+	jsonPathString = jsonPathString[1 : len(jsonPathString)-1]
+
+	// find top level array selectors (outside of quotes and nested brackets)
+	depth := 0
+	start := 0
+	var quote rune
+	for pos, char := range jsonPathString {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+			continue
+		case char == '\'' || char == '"':
+			quote = char
+			continue
+		case char == '[':
+			if depth == 0 {
+				start = pos
+			}
+			depth++
+			continue
+		case char == ']':
+			depth--
+			if depth != 0 {
+				continue
+			}
+		default:
+			continue
+		}
+
+		prefix := jsonPathString[:start]
+		selector := jsonPathString[start : pos+1]
+		suffix := jsonPathString[pos+1:]
+
+		if prefix != "" && isMultiJsonPath("{"+prefix+"}") {
+			// already expanded before the array selector
+			return nil, nil
+		}
+
+		if !isMultiJsonPath("{" + selector + "}") {
+			// single index, eg. [0]
+			continue
+		}
+
+		if suffix != "" && isMultiJsonPath("{"+suffix+"}") {
+			// nested expansions
+			return nil, nil
+		}
+
+		ret := &foreachIndex{
+			array:  &objectPath{root: root},
+			filter: strings.HasPrefix(selector, "[?"),
+			suffix: &objectPath{},
+		}
+
+		if prefix != "" {
+			if ret.array.path, err = compileJsonPath("{" + prefix + "}"); err != nil {
+				return nil, err
+			}
+		}
+
+		// selector is applied to the array itself, it must not be relaxed into a field access
+		ret.selector = jsonpath.New("jsonpath")
+		ret.selector.AllowMissingKeys(true)
+		if err := ret.selector.Parse("{" + selector + "}"); err != nil {
+			return nil, fmt.Errorf(`unable to parse JSONpath "%s": %w`, selector, err)
+		}
+
+		if suffix != "" {
+			if ret.suffix.path, err = compileJsonPath("{" + suffix + "}"); err != nil {
+				return nil, err
+			}
+		}
+
+		return ret, nil
+	}
+
+	return nil, nil
+}
+
+// FindResults returns the results and the source array index of each result
+func (i *foreachIndex) FindResults(element ObjectElement) ([]string, []interface{}, error) {
+	keys := []string{}
+	ret := []interface{}{}
+
+	arrays, err := i.array.FindResults(element)
+	if err != nil || len(arrays) != 1 {
+		return keys, ret, err
+	}
+
+	items, ok := arrays[0].([]interface{})
+	if !ok {
+		return keys, ret, nil
+	}
+
+	indexes := []int{}
+	if i.filter {
+		// filters are checked for each item, the item matches if it's selected from a single item array
+		for num, item := range items {
+			results, err := i.selector.FindResults([]interface{}{item})
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if len(results) > 0 && len(results[0]) > 0 {
+				indexes = append(indexes, num)
+			}
+		}
+	} else {
+		// slices, unions and wildcards are applied to the array indexes
+		positions := make([]interface{}, len(items))
+		for num := range items {
+			positions[num] = num
+		}
+
+		results, err := i.selector.FindResults(positions)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, result := range results {
+			for _, val := range result {
+				if index, ok := val.Interface().(int); ok {
+					indexes = append(indexes, index)
+				}
+			}
+		}
+	}
+
+	for _, index := range indexes {
+		results, err := i.suffix.FindResults(ObjectElement{Root: element.Root, Value: items[index]})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, result := range results {
+			keys = append(keys, strconv.Itoa(index))
+			ret = append(ret, result)
+		}
+	}
+
+	return keys, ret, nil
+}
+
+// trimObjectPathRoot removes the root prefix ("$") of the path, second return value is true if the prefix was found
+func trimObjectPathRoot(path string) (string, bool) {
+	path = strings.TrimSpace(path)
+
+	switch {
+	case strings.HasPrefix(path, "{"+OBJECTPATH_ROOT):
+		return "{" + strings.TrimPrefix(path, "{"+OBJECTPATH_ROOT), true
+	case strings.HasPrefix(path, OBJECTPATH_ROOT):
+		return strings.TrimPrefix(path, OBJECTPATH_ROOT), true
+	}
+
+	return path, false
+}
+
+func compileObjectPath(path string) (*objectPath, error) {
+	ret := &objectPath{}
+	path, ret.root = trimObjectPathRoot(path)
+
+	switch path {
+	case "", "{}", OBJECTPATH_SELF, "{" + OBJECTPATH_SELF + "}":
+		// element (or root object) itself
+		return ret, nil
+	}
+
+	jsonPath, err := compileJsonPath(path)
+	if err != nil {
+		return nil, err
+	}
+	ret.path = jsonPath
+	ret.multi = isMultiJsonPath(path)
+
+	return ret, nil
+}
+
+// isMultiJsonPath returns true if the jsonPath expands into multiple results instead of selecting a single node
+func isMultiJsonPath(path string) bool {
+	jsonPathString, err := get.RelaxedJSONPathExpression(strings.TrimSpace(path))
+	if err != nil {
+		return false
+	}
+
+	parser, err := jsonpath.Parse("jsonpath", jsonPathString)
+	if err != nil {
+		return false
+	}
+
+	var isMulti func(node jsonpath.Node) bool
+	isMulti = func(node jsonpath.Node) bool {
+		switch v := node.(type) {
+		case *jsonpath.ListNode:
+			for _, child := range v.Nodes {
+				if isMulti(child) {
+					return true
+				}
+			}
+		case *jsonpath.ArrayNode:
+			// single index (eg. [0]) has a derived end, everything else is a slice
+			return !v.Params[1].Derived
+		case *jsonpath.FilterNode, *jsonpath.WildcardNode, *jsonpath.RecursiveNode, *jsonpath.UnionNode:
+			return true
+		}
+
+		return false
+	}
+
+	return isMulti(parser.Root)
+}
+
+// FindResults returns all values found by the path (flattened)
+func (p *objectPath) FindResults(element ObjectElement) ([]interface{}, error) {
+	var data interface{} = element.Value
+	if p.root {
+		data = element.Root
+	}
+
+	if p.path == nil {
+		return []interface{}{data}, nil
+	}
+
+	results, err := p.path.FindResults(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []interface{}{}
+	for _, result := range results {
+		for _, val := range result {
+			ret = append(ret, val.Interface())
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForeachElements(t *testing.T) {
+	container := map[string]interface{}{"name": "app", "image": "nginx"}
+	sidecar := map[string]interface{}{"name": "sidecar", "image": "envoy"}
+
+	objectWith := func(containers ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{"b": "2", "a": "1"},
+			},
+			"spec": map[string]interface{}{
+				"containers": containers,
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		object map[string]interface{}
+		keys   []string
+		values []interface{}
+	}{
+		{
+			name:   "array",
+			path:   ".spec.containers",
+			object: objectWith(container, sidecar),
+			keys:   []string{"0", "1"},
+			values: []interface{}{container, sidecar},
+		},
+		{
+			name:   "array with single item",
+			path:   ".spec.containers",
+			object: objectWith(container),
+			keys:   []string{"0"},
+			values: []interface{}{container},
+		},
+		{
+			name:   "wildcard",
+			path:   ".spec.containers[*]",
+			object: objectWith(container, sidecar),
+			keys:   []string{"0", "1"},
+			values: []interface{}{container, sidecar},
+		},
+		{
+			name:   "wildcard matching single map",
+			path:   ".spec.containers[*]",
+			object: objectWith(container),
+			keys:   []string{"0"},
+			values: []interface{}{container},
+		},
+		{
+			name:   "filter matching single map",
+			path:   `.spec.containers[?(@.name=="sidecar")]`,
+			object: objectWith(container, sidecar),
+			keys:   []string{"1"},
+			values: []interface{}{sidecar},
+		},
+		{
+			name:   "filter field",
+			path:   `.spec.containers[?(@.image=="envoy")].name`,
+			object: objectWith(container, sidecar, sidecar),
+			keys:   []string{"1", "2"},
+			values: []interface{}{"sidecar", "sidecar"},
+		},
+		{
+			name:   "filter root",
+			path:   `$.spec.containers[?(@.name!="app")]`,
+			object: objectWith(container, sidecar),
+			keys:   []string{"1"},
+			values: []interface{}{sidecar},
+		},
+		{
+			name:   "slice matching single map",
+			path:   ".spec.containers[0:1]",
+			object: objectWith(container, sidecar),
+			keys:   []string{"0"},
+			values: []interface{}{container},
+		},
+		{
+			name:   "slice",
+			path:   ".spec.containers[1:]",
+			object: objectWith(container, sidecar, container),
+			keys:   []string{"1", "2"},
+			values: []interface{}{sidecar, container},
+		},
+		{
+			name:   "union",
+			path:   ".spec.containers[0,2]",
+			object: objectWith(container, sidecar, sidecar),
+			keys:   []string{"0", "2"},
+			values: []interface{}{container, sidecar},
+		},
+		{
+			name:   "wildcard field",
+			path:   ".spec.containers[*].name",
+			object: objectWith(container, sidecar),
+			keys:   []string{"0", "1"},
+			values: []interface{}{"app", "sidecar"},
+		},
+		{
+			name:   "wildcard missing field",
+			path:   ".spec.containers[*].name",
+			object: objectWith(map[string]interface{}{"image": "busybox"}, sidecar),
+			keys:   []string{"1"},
+			values: []interface{}{"sidecar"},
+		},
+		{
+			name:   "single index",
+			path:   ".spec.containers[1]",
+			object: objectWith(container, sidecar),
+			keys:   []string{"image", "name"},
+			values: []interface{}{"envoy", "sidecar"},
+		},
+		{
+			name:   "map sorted by key",
+			path:   ".metadata.labels",
+			object: objectWith(),
+			keys:   []string{"a", "b"},
+			values: []interface{}{"1", "2"},
+		},
+		{
+			name:   "missing",
+			path:   ".spec.volumes[*]",
+			object: objectWith(container),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metric := &ConfigMetric{Foreach: &ConfigMetricForeach{Path: test.path}}
+			if err := metric.Foreach.Compile(); err != nil {
+				t.Fatal(err)
+			}
+
+			elements, err := metric.Elements(NewObjectElement(test.object))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			keys := []string{}
+			values := []interface{}{}
+			for _, element := range elements {
+				keys = append(keys, *element.Key)
+				values = append(values, element.Value)
+
+				if !reflect.DeepEqual(element.Root, test.object) {
+					t.Errorf("element root is not the object")
+				}
+			}
+
+			if len(test.keys) == 0 && len(keys) == 0 {
+				return
+			}
+
+			if !reflect.DeepEqual(keys, test.keys) {
+				t.Errorf("expected keys %v, got %v", test.keys, keys)
+			}
+
+			if !reflect.DeepEqual(values, test.values) {
+				t.Errorf("expected values %v, got %v", test.values, values)
+			}
+		})
+	}
+}
+
+func TestIsMultiJsonPath(t *testing.T) {
+	tests := map[string]bool{
+		".spec.containers":                       false,
+		".spec.containers[0]":                    false,
+		".spec.containers[-1].name":              false,
+		"{.spec.containers}":                     false,
+		".spec.containers[*]":                    true,
+		".spec.containers[*].name":               true,
+		".spec.containers[0:2]":                  true,
+		".spec.containers[0,1]":                  true,
+		`.subjects[?(@.kind=="ServiceAccount")]`: true,
+		"..name":                                 true,
+		".metadata.labels.*":                     true,
+	}
+
+	for path, expected := range tests {
+		if multi := isMultiJsonPath(path); multi != expected {
+			t.Errorf("%s: expected multi=%v, got %v", path, expected, multi)
+		}
+	}
+}
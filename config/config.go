@@ -3,12 +3,15 @@ package config
 import (
 	"fmt"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/cel-go/cel"
 	"github.com/webdevops/go-common/kubernetes/selector"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/util/jsonpath"
 	"k8s.io/kubectl/pkg/cmd/get"
@@ -17,6 +20,10 @@ import (
 const (
 	KUBE_SELECTOR_ERROR = "<error>"
 	KUBE_SELECTOR_NONE  = "<none>"
+
+	// metrics of the exporter itself, reserved names
+	METRIC_NAME_ANNOTATION_METRICS_INVALID = "kube_resource_exporter_annotation_metrics_invalid"
+	METRIC_NAME_CONVERSION_FAILED          = "kube_resource_exporter_conversion_failed"
 )
 
 type (
@@ -30,47 +37,126 @@ type (
 		Selector *selector.LabelSelector `yaml:"selector"`
 
 		Metrics []*ConfigMetric `yaml:"metrics"`
+
+		AnnotationMetrics *ConfigAnnotationMetrics `yaml:"annotationMetrics"`
+
+		// labels of related objects added to all metrics (not to annotation metrics)
+		Join []*ConfigResourceJoin `yaml:"join"`
 	}
 
 	ConfigMetric struct {
 		Name   string                        `yaml:"name"`
 		Help   string                        `yaml:"help"`
+		Mode   string                        `yaml:"mode"`
 		Value  *ConfigMetricValue            `yaml:"value"`
 		Labels map[string]*ConfigMetricLabel `yaml:"labels"`
 
+		Conditions *ConfigMetricConditions `yaml:"conditions"`
+
+		Reference *ConfigMetricReference `yaml:"reference"`
+
+		LabelsFrom []*ConfigMetricLabelsFrom `yaml:"labelsFrom"`
+
+		Foreach *ConfigMetricForeach `yaml:"foreach"`
+
 		Filters []*ConfigMetricFilter `yaml:"filters"`
+
+		// additional metrics generated by this metric (eg. by mode conditions)
+		_companions []*ConfigMetric
+
+		// joins of the resource
+		_joins []*ConfigResourceJoin
 	}
 
 	ConfigMetricValue struct {
 		*ConfigMetricJsonPath `yaml:",inline"`
 		Value                 *float64 `yaml:"value"`
+		Expression            string   `yaml:"expression"`
+		_expression           *celExpression
+		Aggregate             string                        `yaml:"aggregate"`
+		Mapping               *ConfigMetricValueMapping     `yaml:"mapping"`
+		Operations            []*ConfigMetricValueOperation `yaml:"operations"`
+
+		// structural value mode (length, exists, isEmpty)
+		Mode  string `yaml:"mode"`
+		_mode string
+
+		// internal value function (eg. for companion metrics)
+		_func func(element ObjectElement) (*float64, error)
+
+		// age or timeuntil, value is calculated relative to the scrape time
+		_relativeTime string
 	}
 
 	ConfigMetricLabel struct {
 		*ConfigMetricJsonPath `yaml:",inline"`
 		Value                 string `yaml:"value"`
+		Expression            string `yaml:"expression"`
+		_expression           *celExpression
+		Aggregate             string  `yaml:"aggregate"`
+		Separator             *string `yaml:"separator"`
+
+		// owner found by following the controller ownerReferences (eg. Pod -> ReplicaSet -> Deployment)
+		Owner *ConfigMetricLabelOwner `yaml:"owner"`
 	}
 
 	ConfigMetricJsonPath struct {
 		Path    string `yaml:"jsonPath" json:"jsonPath"`
-		_path   *jsonpath.JSONPath
-		Convert []*string `yaml:"convert"`
-
-		Template *string `yaml:"template"`
+		_path   *objectPath
+		Jq      string `yaml:"jq" json:"jq"`
+		_jq     *jqQuery
+		Convert []*ConfigMetricConvert `yaml:"convert" json:"convert"`
+
+		// latest managedFields entry owning a field (instead of jsonPath or jq)
+		ManagedFields *ConfigMetricManagedFields `yaml:"managedFields" json:"managedFields"`
+
+		// decoding (eg. helmRelease) or parsing (json, yaml) of the found value into a nested document,
+		// documentPath is used to extract the result from the document
+		Decode        string `yaml:"decode" json:"decode"`
+		Parse         string `yaml:"parse" json:"parse"`
+		_decode       string
+		_decodeName   string
+		DocumentPath  string `yaml:"documentPath" json:"documentPath"`
+		_documentPath *objectPath
+
+		Template  *string `yaml:"template"`
+		_template *configTemplate
 	}
 
 	ConfigMetricFilter struct {
 		Path  string `yaml:"jsonPath" json:"jsonPath"`
-		_path *jsonpath.JSONPath
+		_path *objectPath
+
+		Expression  string `yaml:"expression"`
+		_expression *celExpression
 
 		Regex  string `yaml:"regex"`
 		_regex *regexp.Regexp
+
+		// version constraint, eg. "<1.8.0"
+		SemverRange  string `yaml:"semverRange"`
+		_semverRange *semver.Constraints
 	}
 
 	MetricJsonPath string
 )
 
 var (
+	// allowed result types of CEL expressions for values and labels
+	celResultTypes = []*cel.Type{
+		cel.IntType,
+		cel.UintType,
+		cel.DoubleType,
+		cel.BoolType,
+		cel.StringType,
+		cel.TimestampType,
+		cel.DurationType,
+		cel.ListType(cel.DynType),
+	}
+
+	metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegexp  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 	timeFormats = []string{
 		// preferred format
 		time.RFC3339,
@@ -93,18 +179,35 @@ var (
 	}
 )
 
-func (m *Config) Compile() error {
+// Compile compiles all resources, baseLabelNames are the labels added to every metric by the exporter
+func (m *Config) Compile(baseLabelNames []string) error {
 	for _, row := range m.Resources {
-		err := row.Compile()
+		err := row.Compile(baseLabelNames)
 		if err != nil {
 			return err
 		}
 	}
 
+	// metric name collisions (including generated companion metrics)
+	seenMetrics := map[string]bool{}
+	for _, row := range m.Resources {
+		for _, metric := range row.AllMetrics() {
+			switch metric.Name {
+			case METRIC_NAME_ANNOTATION_METRICS_INVALID, METRIC_NAME_CONVERSION_FAILED:
+				return fmt.Errorf(`metric name "%s" is reserved for metrics of the exporter itself`, metric.Name)
+			}
+
+			if seenMetrics[metric.Name] {
+				return fmt.Errorf(`metric "%s" is defined multiple times`, metric.Name)
+			}
+			seenMetrics[metric.Name] = true
+		}
+	}
+
 	return nil
 }
 
-func (m *ConfigResource) Compile() error {
+func (m *ConfigResource) Compile(baseLabelNames []string) error {
 	if m.Version == "" {
 		return fmt.Errorf("version is required")
 	}
@@ -128,6 +231,50 @@ func (m *ConfigResource) Compile() error {
 		}
 	}
 
+	// joins
+	joinLabelNames := map[string]bool{}
+	for _, join := range m.Join {
+		if join == nil {
+			return fmt.Errorf(`join of resource "%s/%s/%s" is empty`, m.Group, m.Version, m.Resource)
+		}
+
+		if err := join.Compile(); err != nil {
+			return fmt.Errorf(`invalid join of resource "%s/%s/%s": %w`, m.Group, m.Version, m.Resource, err)
+		}
+
+		for _, labelName := range join.LabelNames() {
+			if joinLabelNames[labelName] {
+				return fmt.Errorf(`join label "%s" of resource "%s/%s/%s" is defined multiple times`, labelName, m.Group, m.Version, m.Resource)
+			}
+			joinLabelNames[labelName] = true
+		}
+	}
+
+	if len(m.Join) > 0 {
+		for _, metric := range m.AllMetrics() {
+			for _, labelName := range metric.LabelNames() {
+				if joinLabelNames[labelName] {
+					return fmt.Errorf(`label "%s" of metric "%s" is already defined by a join`, labelName, metric.Name)
+				}
+			}
+			metric._joins = m.Join
+		}
+	}
+
+	// base label collisions (including labels of joins and companion metrics)
+	for _, metric := range m.AllMetrics() {
+		if err := metric.checkBaseLabels(baseLabelNames); err != nil {
+			return err
+		}
+	}
+
+	// annotation metrics
+	if m.AnnotationMetrics != nil {
+		if err := m.AnnotationMetrics.Compile(baseLabelNames); err != nil {
+			return fmt.Errorf(`unable to compile annotationMetrics for resource "%s/%s/%s": %w`, m.Group, m.Version, m.Resource, err)
+		}
+	}
+
 	return nil
 }
 
@@ -136,37 +283,195 @@ func (m *ConfigMetric) Compile() error {
 		return fmt.Errorf("name is required")
 	}
 
+	if !metricNameRegexp.MatchString(m.Name) {
+		return fmt.Errorf(`metric name "%s" is not a valid Prometheus metric name`, m.Name)
+	}
+
+	// mode
+	m.Mode = strings.ToLower(m.Mode)
+	switch m.Mode {
+	case METRIC_MODE_DEFAULT:
+		if m.Conditions != nil {
+			return fmt.Errorf(`conditions of metric "%s" are only supported for mode "%s"`, m.Name, METRIC_MODE_CONDITIONS)
+		}
+		if m.Reference != nil {
+			return fmt.Errorf(`reference of metric "%s" is only supported for mode "%s"`, m.Name, METRIC_MODE_REFERENCE)
+		}
+	case METRIC_MODE_CONDITIONS:
+		if err := m.compileConditions(); err != nil {
+			return fmt.Errorf(`invalid metric "%s": %w`, m.Name, err)
+		}
+	case METRIC_MODE_REFERENCE:
+		if err := m.compileReference(); err != nil {
+			return fmt.Errorf(`invalid metric "%s": %w`, m.Name, err)
+		}
+	default:
+		return fmt.Errorf(`mode "%s" of metric "%s" is not supported`, m.Mode, m.Name)
+	}
+
+	if m.Value == nil {
+		return fmt.Errorf(`value is required for metric "%s"`, m.Name)
+	}
+
+	for labelName, labelConfig := range m.Labels {
+		if !labelNameRegexp.MatchString(labelName) {
+			return fmt.Errorf(`label name "%s" of metric "%s" is not a valid Prometheus label name`, labelName, m.Name)
+		}
+
+		if labelConfig == nil {
+			return fmt.Errorf(`label "%s" of metric "%s" is empty`, labelName, m.Name)
+		}
+	}
+
+	// foreach
+	if m.Foreach != nil {
+		if err := m.Foreach.Compile(); err != nil {
+			return fmt.Errorf(`invalid foreach of metric "%s": %w`, m.Name, err)
+		}
+	}
+
+	// labelsFrom
+	for _, labelsFromConfig := range m.LabelsFrom {
+		if labelsFromConfig == nil {
+			return fmt.Errorf(`labelsFrom of metric "%s" is empty`, m.Name)
+		}
+
+		if err := labelsFromConfig.Compile(); err != nil {
+			return fmt.Errorf(`invalid labelsFrom of metric "%s": %w`, m.Name, err)
+		}
+	}
+
+	// label name collisions
+	seenLabels := map[string]bool{}
+	for _, labelName := range m.LabelNames() {
+		if seenLabels[labelName] {
+			return fmt.Errorf(`label "%s" of metric "%s" is defined multiple times`, labelName, m.Name)
+		}
+		seenLabels[labelName] = true
+	}
+
 	// value path
-	if m.Value.ConfigMetricJsonPath != nil && m.Value.Path != "" {
-		if path, err := compileJsonPath(m.Value.Path); err == nil {
-			m.Value._path = path
+	if m.Value.ConfigMetricJsonPath == nil {
+		m.Value.ConfigMetricJsonPath = &ConfigMetricJsonPath{}
+	}
+
+	if err := m.Value.ConfigMetricJsonPath.compile(); err != nil {
+		return fmt.Errorf(`invalid value of metric "%s": %w`, m.Name, err)
+	}
+
+	if err := m.Value.compileConvert(valueConversions); err != nil {
+		return fmt.Errorf(`invalid value of metric "%s": %w`, m.Name, err)
+	}
+
+	if err := m.Value.compileRelativeTime(); err != nil {
+		return fmt.Errorf(`invalid value of metric "%s": %w`, m.Name, err)
+	}
+
+	// value expression
+	if m.Value.Expression != "" {
+		if m.Value.Path != "" || m.Value.Jq != "" || m.Value.ManagedFields != nil {
+			return fmt.Errorf(`value of metric "%s" can only use one of jsonPath, jq, managedFields or expression`, m.Name)
+		}
+
+		if expression, err := compileCelExpression(m.Value.Expression, celResultTypes...); err == nil {
+			m.Value._expression = expression
 		} else {
-			return err
+			return fmt.Errorf(`invalid value of metric "%s": %w`, m.Name, err)
 		}
 	}
 
+	if err := m.Value.compileAggregate(); err != nil {
+		return fmt.Errorf(`invalid value of metric "%s": %w`, m.Name, err)
+	}
+
+	if m.Value.Mapping != nil {
+		if err := m.Value.Mapping.Compile(); err != nil {
+			return fmt.Errorf(`invalid value mapping of metric "%s": %w`, m.Name, err)
+		}
+	}
+
+	if err := m.Value.compileMode(); err != nil {
+		return fmt.Errorf(`invalid value of metric "%s": %w`, m.Name, err)
+	}
+
+	if err := m.Value.compileOperations(); err != nil {
+		return fmt.Errorf(`invalid value of metric "%s": %w`, m.Name, err)
+	}
+
 	// labels path
-	for _, labelConfig := range m.Labels {
-		if labelConfig.ConfigMetricJsonPath != nil && labelConfig.Path != "" {
-			if path, err := compileJsonPath(labelConfig.Path); err == nil {
-				labelConfig._path = path
+	for labelName, labelConfig := range m.Labels {
+		if labelConfig.ConfigMetricJsonPath == nil {
+			labelConfig.ConfigMetricJsonPath = &ConfigMetricJsonPath{}
+		}
+
+		if err := labelConfig.ConfigMetricJsonPath.compile(); err != nil {
+			return fmt.Errorf(`invalid label "%s" of metric "%s": %w`, labelName, m.Name, err)
+		}
+
+		if err := labelConfig.compileConvert(labelConversions); err != nil {
+			return fmt.Errorf(`invalid label "%s" of metric "%s": %w`, labelName, m.Name, err)
+		}
+
+		// label expression
+		if labelConfig.Expression != "" {
+			if labelConfig.Path != "" || labelConfig.Jq != "" || labelConfig.ManagedFields != nil {
+				return fmt.Errorf(`label "%s" of metric "%s" can only use one of jsonPath, jq, managedFields or expression`, labelName, m.Name)
+			}
+
+			if expression, err := compileCelExpression(labelConfig.Expression, celResultTypes...); err == nil {
+				labelConfig._expression = expression
 			} else {
-				return err
+				return fmt.Errorf(`invalid label "%s" of metric "%s": %w`, labelName, m.Name, err)
+			}
+		}
+
+		if err := labelConfig.compileAggregate(); err != nil {
+			return fmt.Errorf(`invalid label "%s" of metric "%s": %w`, labelName, m.Name, err)
+		}
+
+		// label owner
+		if labelConfig.Owner != nil {
+			if labelConfig.Path != "" || labelConfig.Jq != "" || labelConfig.ManagedFields != nil || labelConfig.Expression != "" || labelConfig.Aggregate != "" {
+				return fmt.Errorf(`label "%s" of metric "%s" can not use owner with jsonPath, jq, managedFields, expression or aggregate`, labelName, m.Name)
+			}
+
+			if labelConfig.Decode != "" || labelConfig.Parse != "" {
+				return fmt.Errorf(`label "%s" of metric "%s" can not use owner with decode or parse`, labelName, m.Name)
+			}
+
+			if err := labelConfig.Owner.Compile(); err != nil {
+				return fmt.Errorf(`invalid label "%s" of metric "%s": %w`, labelName, m.Name, err)
 			}
 		}
 	}
 
 	// filters
 	for _, filterConfig := range m.Filters {
-		if filterConfig.Path == "" {
-			return fmt.Errorf(`jsonPath must be set for filters`)
+		if (filterConfig.Path == "") == (filterConfig.Expression == "") {
+			return fmt.Errorf(`either jsonPath or expression must be set for filters`)
 		}
 
 		// compile jsonPath
-		if path, err := compileJsonPath(filterConfig.Path); err == nil {
-			filterConfig._path = path
-		} else {
-			return err
+		if filterConfig.Path != "" {
+			if path, err := compileObjectPath(filterConfig.Path); err == nil {
+				filterConfig._path = path
+			} else {
+				return err
+			}
+		}
+
+		// compile expression, without regex or semverRange the expression must return a boolean
+		if filterConfig.Expression != "" {
+			filterTypes := []*cel.Type{cel.BoolType}
+			if filterConfig.Regex != "" || filterConfig.SemverRange != "" {
+				filterTypes = celResultTypes
+			}
+
+			if expression, err := compileCelExpression(filterConfig.Expression, filterTypes...); err == nil {
+				filterConfig._expression = expression
+			} else {
+				return fmt.Errorf(`invalid filter of metric "%s": %w`, m.Name, err)
+			}
 		}
 
 		// compile regex
@@ -178,11 +483,42 @@ func (m *ConfigMetric) Compile() error {
 
 			filterConfig._regex = filterRegex
 		}
+
+		if err := filterConfig.compileSemverRange(); err != nil {
+			return fmt.Errorf(`invalid filter of metric "%s": %w`, m.Name, err)
+		}
+	}
+
+	// companion metrics
+	if m.Mode == METRIC_MODE_CONDITIONS {
+		if err := m.compileConditionsCompanions(); err != nil {
+			return fmt.Errorf(`invalid metric "%s": %w`, m.Name, err)
+		}
 	}
 
 	return nil
 }
 
+// AllMetrics returns all metrics of the resource including generated companion metrics
+func (m *ConfigResource) AllMetrics() []*ConfigMetric {
+	ret := []*ConfigMetric{}
+	for _, metric := range m.Metrics {
+		ret = append(ret, metric.AllMetrics()...)
+	}
+
+	return ret
+}
+
+// Joins returns the joins of the resource which add labels to the metric
+func (m *ConfigMetric) Joins() []*ConfigResourceJoin {
+	return m._joins
+}
+
+// AllMetrics returns the metric itself and all generated companion metrics
+func (m *ConfigMetric) AllMetrics() []*ConfigMetric {
+	return append([]*ConfigMetric{m}, m._companions...)
+}
+
 func (m *ConfigResource) KubeMetaListOptions() metav1.ListOptions {
 	opts := metav1.ListOptions{}
 	if !m.Selector.IsEmpty() {
@@ -197,21 +533,238 @@ func (m *ConfigResource) KubeMetaListOptions() metav1.ListOptions {
 	return opts
 }
 
-func (m *ConfigMetricJsonPath) JsonPath() *jsonpath.JSONPath {
+// checkBaseLabels returns an error if a label of the metric collides with a base label of the exporter
+func (m *ConfigMetric) checkBaseLabels(baseLabelNames []string) error {
+	labelNames := m.LabelNames()
+	for _, baseLabelName := range baseLabelNames {
+		if slices.Contains(labelNames, baseLabelName) {
+			return fmt.Errorf(`label "%s" of metric "%s" is conflicting with base label "%s"`, baseLabelName, m.Name, baseLabelName)
+		}
+	}
+
+	return nil
+}
+
+// LabelNames returns the sorted list of label names generated by this metric (without base labels)
+func (m *ConfigMetric) LabelNames() []string {
+	ret := []string{}
+	for labelName := range m.Labels {
+		ret = append(ret, labelName)
+	}
+
+	for _, labelsFromConfig := range m.LabelsFrom {
+		ret = append(ret, labelsFromConfig.LabelNames()...)
+	}
+
+	if m.Foreach != nil && m.Foreach.KeyLabel != "" {
+		ret = append(ret, m.Foreach.KeyLabel)
+	}
+
+	if m.Reference != nil {
+		ret = append(ret, REFERENCE_LABEL_NAME, REFERENCE_LABEL_NAMESPACE)
+	}
+
+	for _, join := range m._joins {
+		ret = append(ret, join.LabelNames()...)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+// compile compiles the jsonPath or jq query and the template
+func (m *ConfigMetricJsonPath) compile() error {
+	if m.Path != "" && m.Jq != "" {
+		return fmt.Errorf(`only one of jsonPath or jq can be used`)
+	}
+
+	if m.ManagedFields != nil {
+		if m.Path != "" || m.Jq != "" || m.Decode != "" || m.Parse != "" {
+			return fmt.Errorf(`managedFields can not be combined with jsonPath, jq, decode or parse`)
+		}
+
+		if err := m.ManagedFields.Compile(); err != nil {
+			return err
+		}
+	}
+
+	if m.Path != "" {
+		path, err := compileObjectPath(m.Path)
+		if err != nil {
+			return err
+		}
+		m._path = path
+	}
+
+	if m.Jq != "" {
+		query, err := compileJqQuery(m.Jq)
+		if err != nil {
+			return err
+		}
+		m._jq = query
+	}
+
+	if err := m.compileDecode(); err != nil {
+		return err
+	}
+
+	return m.compileTemplate()
+}
+
+// FindResults returns all values found by the jsonPath (flattened) or all outputs of the jq query,
+// results of decoded documents if decode is used
+func (m *ConfigMetricJsonPath) FindResults(element ObjectElement) ([]interface{}, error) {
 	if m == nil {
-		return nil
+		return nil, nil
+	}
+
+	var results []interface{}
+	var err error
+	switch {
+	case m.ManagedFields != nil:
+		return m.ManagedFields.FindResults(element)
+	case m._jq != nil:
+		results, err = m._jq.FindResults(element)
+	case m._path != nil:
+		results, err = m._path.FindResults(element)
+	default:
+		return nil, nil
+	}
+
+	if err != nil || m._decode == "" {
+		return results, err
+	}
+
+	return m.findDocumentResults(element, results)
+}
+
+func (m *ConfigMetricValue) findResults(element ObjectElement) ([]interface{}, error) {
+	if m._expression != nil {
+		return m._expression.FindResults(element)
 	}
 
-	return m._path
+	return m.ConfigMetricJsonPath.FindResults(element)
 }
 
-func (m *ConfigMetricJsonPath) ParseLabel(val interface{}) (ret string) {
+func (m *ConfigMetricLabel) findResults(element ObjectElement) ([]interface{}, error) {
+	if m._expression != nil {
+		return m._expression.FindResults(element)
+	}
+
+	return m.ConfigMetricJsonPath.FindResults(element)
+}
+
+func (m *ConfigMetricFilter) findResults(element ObjectElement) ([]interface{}, error) {
+	if m._expression != nil {
+		return m._expression.FindResults(element)
+	}
+
+	return m._path.FindResults(element)
+}
+
+// FindValue returns the metric value found in element (nil if not found),
+// operations are applied to the final value (after aggregation)
+func (m *ConfigMetricValue) FindValue(element ObjectElement) (*float64, error) {
+	if m._func != nil {
+		return m._func(element)
+	}
+
+	val, err := m.findValue(element)
+	if err != nil || val == nil || len(m.Operations) == 0 {
+		return val, err
+	}
+
+	return m.DoOperations(element, *val)
+}
+
+// findValue returns the (aggregated) value found in element without operations
+func (m *ConfigMetricValue) findValue(element ObjectElement) (*float64, error) {
+	results, err := m.findResults(element)
+	if err != nil {
+		return nil, err
+	}
+
+	if m._mode != VALUE_MODE_DEFAULT {
+		return m.modeValue(results), nil
+	}
+
+	if m.Aggregate == "" {
+		// without aggregation only exactly one result is allowed
+		if len(results) == 1 {
+			return m.parseValue(results[0])
+		}
+
+		return nil, nil
+	}
+
+	if m.Aggregate == AGGREGATE_COUNT {
+		// count all found elements, also non-numeric ones
+		val := float64(len(results))
+		return &val, nil
+	}
+
+	values := []float64{}
+	for _, val := range results {
+		v, err := m.parseValue(val)
+		if err != nil {
+			return nil, err
+		}
+
+		if v != nil {
+			values = append(values, *v)
+		}
+	}
+
+	return m.AggregateValues(values), nil
+}
+
+// FindLabel returns the label value found in element, second return value is false if nothing was found
+func (m *ConfigMetricLabel) FindLabel(element ObjectElement) (string, bool, error) {
+	results, err := m.findResults(element)
+	if err != nil {
+		return "", false, err
+	}
+
+	if m.Aggregate == "" {
+		// without aggregation only exactly one result is allowed
+		if len(results) == 1 {
+			label, err := m.ParseLabel(results[0])
+			if err != nil {
+				return "", false, err
+			}
+			return label, true, nil
+		}
+
+		return "", false, nil
+	}
+
+	if len(results) == 0 {
+		return "", false, nil
+	}
+
+	values := []string{}
+	for _, val := range results {
+		label, err := m.ParseLabel(val)
+		if err != nil {
+			return "", false, err
+		}
+		values = append(values, label)
+	}
+
+	return m.AggregateLabels(values), true, nil
+}
+
+func (m *ConfigMetricJsonPath) ParseLabel(val interface{}) (string, error) {
+	ret := ""
+
 	// convert type
 	switch v := val.(type) {
 	case float64:
 		ret = fmt.Sprintf("%f", v)
 	case int64:
 		ret = fmt.Sprintf("%d", v)
+	case uint64:
+		ret = fmt.Sprintf("%d", v)
 	case string:
 		ret = v
 	case bool:
@@ -225,13 +778,15 @@ func (m *ConfigMetricJsonPath) ParseLabel(val interface{}) (ret string) {
 	return m.DoConvertLabel(ret)
 }
 
-func (m *ConfigMetricJsonPath) ParseValue(val interface{}) (ret *float64) {
+func (m *ConfigMetricJsonPath) ParseValue(val interface{}) (*float64, error) {
 	valueString := ""
 	switch v := val.(type) {
 	case float64:
 		valueString = fmt.Sprintf("%f", v)
 	case int64:
 		valueString = fmt.Sprintf("%d", v)
+	case uint64:
+		valueString = fmt.Sprintf("%d", v)
 	case string:
 		valueString = v
 	case bool:
@@ -245,21 +800,28 @@ func (m *ConfigMetricJsonPath) ParseValue(val interface{}) (ret *float64) {
 	return m.DoConvertValue(valueString)
 }
 
-func (m *ConfigMetric) IsValidObject(object unstructured.Unstructured) bool {
+func (m *ConfigMetric) IsValidObject(element ObjectElement) bool {
 	// no filters = is valid
 	if len(m.Filters) == 0 {
 		return true
 	}
 
 	for _, filterConfig := range m.Filters {
-		if results, err := filterConfig._path.FindResults(object.Object); err == nil {
-			if len(results) == 1 && len(results[0]) == 1 {
-				val := results[0][0].Interface()
+		if results, err := filterConfig.findResults(element); err == nil {
+			if len(results) == 1 {
+				val := results[0]
 				if val == nil {
 					// no value, object is filtered
 					return false
 				}
 
+				// boolean expression result
+				if filterConfig._expression != nil && filterConfig._regex == nil && filterConfig._semverRange == nil {
+					if v, ok := val.(bool); ok && !v {
+						return false
+					}
+				}
+
 				// convert to string and check if there is a value
 				value := fmt.Sprintf("%v", val)
 				if value == "" {
@@ -272,9 +834,19 @@ func (m *ConfigMetric) IsValidObject(object unstructured.Unstructured) bool {
 						return false
 					}
 				}
+
+				// check version constraint
+				if filterConfig._semverRange != nil {
+					if !filterConfig.matchSemverRange(value) {
+						return false
+					}
+				}
 			} else {
 				return false
 			}
+		} else if filterConfig._expression != nil {
+			// expression could not be evaluated (eg. missing field), object is filtered
+			return false
 		}
 	}
 
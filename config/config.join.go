@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// JOIN_DEFAULT_RELATED_KEY is used to find the related object if no relatedKey is configured
+	JOIN_DEFAULT_RELATED_KEY = ".metadata.name"
+)
+
+type (
+	// ConfigResourceJoin adds labels of a related object (eg. the namespace or node) to all metrics of the resource
+	ConfigResourceJoin struct {
+		*schema.GroupVersionResource `yaml:",inline"`
+
+		// key of the object (eg. .metadata.namespace or .spec.nodeName)
+		Key *ConfigMetricJsonPath `yaml:"key"`
+
+		// key of the related object (default: .metadata.name)
+		RelatedKey *ConfigMetricJsonPath `yaml:"relatedKey"`
+
+		// related objects are only matched inside the namespace of the object
+		SameNamespace bool `yaml:"sameNamespace"`
+
+		// labels extracted from the related object
+		Labels map[string]*ConfigResourceJoinLabel `yaml:"labels"`
+	}
+
+	ConfigResourceJoinLabel struct {
+		*ConfigMetricJsonPath `yaml:",inline"`
+
+		// default value if the related object or the value was not found
+		Value string `yaml:"value"`
+	}
+)
+
+func (m *ConfigResourceJoin) Compile() error {
+	if m.GroupVersionResource == nil || m.Version == "" || m.Resource == "" {
+		return fmt.Errorf("version and resource are required")
+	}
+
+	if m.Key == nil || (m.Key.Path == "" && m.Key.Jq == "") {
+		return fmt.Errorf("key with jsonPath or jq is required")
+	}
+
+	if m.RelatedKey == nil {
+		m.RelatedKey = &ConfigMetricJsonPath{Path: JOIN_DEFAULT_RELATED_KEY}
+	}
+
+	for name, key := range map[string]*ConfigMetricJsonPath{"key": m.Key, "relatedKey": m.RelatedKey} {
+		if key.ManagedFields != nil || key.Template != nil {
+			return fmt.Errorf(`%s can not use managedFields or template`, name)
+		}
+
+		if err := key.compile(); err != nil {
+			return fmt.Errorf(`invalid %s: %w`, name, err)
+		}
+
+		if err := key.compileConvert(labelConversions); err != nil {
+			return fmt.Errorf(`invalid %s: %w`, name, err)
+		}
+	}
+
+	if len(m.Labels) == 0 {
+		return fmt.Errorf("labels are required")
+	}
+
+	for labelName, labelConfig := range m.Labels {
+		if !labelNameRegexp.MatchString(labelName) {
+			return fmt.Errorf(`label name "%s" is not a valid Prometheus label name`, labelName)
+		}
+
+		if labelConfig == nil {
+			return fmt.Errorf(`label "%s" is empty`, labelName)
+		}
+
+		if labelConfig.ConfigMetricJsonPath == nil {
+			labelConfig.ConfigMetricJsonPath = &ConfigMetricJsonPath{}
+		}
+
+		if err := labelConfig.compile(); err != nil {
+			return fmt.Errorf(`invalid label "%s": %w`, labelName, err)
+		}
+
+		if err := labelConfig.compileConvert(labelConversions); err != nil {
+			return fmt.Errorf(`invalid label "%s": %w`, labelName, err)
+		}
+	}
+
+	return nil
+}
+
+// LabelNames returns the sorted label names of the join
+func (m *ConfigResourceJoin) LabelNames() []string {
+	ret := []string{}
+	for labelName := range m.Labels {
+		ret = append(ret, labelName)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+// DefaultLabels returns the labels used if no related object was found
+func (m *ConfigResourceJoin) DefaultLabels() map[string]string {
+	ret := map[string]string{}
+	for labelName, labelConfig := range m.Labels {
+		ret[labelName] = labelConfig.Value
+	}
+
+	return ret
+}
+
+// ObjectKey returns the index key of the object, second return value is false if no key was found
+func (m *ConfigResourceJoin) ObjectKey(object map[string]interface{}) (string, bool, error) {
+	return m.indexKey(m.Key, object)
+}
+
+// RelatedObjectKey returns the index key of the related object, second return value is false if no key was found
+func (m *ConfigResourceJoin) RelatedObjectKey(object map[string]interface{}) (string, bool, error) {
+	return m.indexKey(m.RelatedKey, object)
+}
+
+// indexKey returns the key (prefixed by the namespace if sameNamespace is used), only exactly one non-empty result is a key
+func (m *ConfigResourceJoin) indexKey(key *ConfigMetricJsonPath, object map[string]interface{}) (string, bool, error) {
+	results, err := key.FindResults(NewObjectElement(object))
+	if err != nil || len(results) != 1 {
+		return "", false, err
+	}
+
+	ret, err := key.ParseLabel(results[0])
+	if err != nil || ret == "" {
+		return "", false, err
+	}
+
+	if m.SameNamespace {
+		metadata, _ := object["metadata"].(map[string]interface{})
+		namespace, _ := metadata["namespace"].(string)
+		ret = namespace + "/" + ret
+	}
+
+	return ret, true, nil
+}
+
+// FindLabels returns the labels extracted from the related object
+func (m *ConfigResourceJoin) FindLabels(object map[string]interface{}) (map[string]string, error) {
+	element := NewObjectElement(object)
+
+	ret := m.DefaultLabels()
+	for labelName, labelConfig := range m.Labels {
+		results, err := labelConfig.FindResults(element)
+		if err != nil {
+			return nil, err
+		}
+
+		value := ret[labelName]
+		if len(results) == 1 {
+			if value, err = labelConfig.ParseLabel(results[0]); err != nil {
+				return nil, err
+			}
+		}
+
+		if labelConfig.HasTemplate() {
+			if value, err = labelConfig.RenderTemplate(TemplateData{Value: value, Object: object, Element: object}); err != nil {
+				return nil, err
+			}
+		}
+
+		ret[labelName] = value
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itchyny/gojq"
+)
+
+const (
+	// JQ_TIMEOUT limits the runtime of each jq query evaluation
+	JQ_TIMEOUT = 5 * time.Second
+
+	// JQ_VAR_ROOT references the whole object (eg. if used inside foreach)
+	JQ_VAR_ROOT = "$root"
+)
+
+type (
+	jqQuery struct {
+		query   string
+		code    *gojq.Code
+		useRoot bool
+		timeout time.Duration
+	}
+
+	// jqValue lazily normalizes a value for gojq, the normalized value is built only once
+	// and shared by all queries evaluated against the same object (or element)
+	jqValue struct {
+		once       sync.Once
+		value      interface{}
+		normalized interface{}
+	}
+)
+
+func newJqValue(value interface{}) *jqValue {
+	return &jqValue{value: value}
+}
+
+// normalizedValue returns the normalized value, v can be nil if the element was not created by NewObjectElement
+func (v *jqValue) normalizedValue(fallback interface{}) interface{} {
+	if v == nil {
+		return jqNormalize(fallback)
+	}
+
+	v.once.Do(func() {
+		v.normalized = jqNormalize(v.value)
+	})
+
+	return v.normalized
+}
+
+func compileJqQuery(query string) (*jqQuery, error) {
+	parsedQuery, err := gojq.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to parse jq query "%s": %w`, query, err)
+	}
+
+	code, err := gojq.Compile(parsedQuery, gojq.WithVariables([]string{JQ_VAR_ROOT}))
+	if err != nil {
+		return nil, fmt.Errorf(`unable to compile jq query "%s": %w`, query, err)
+	}
+
+	return &jqQuery{
+		query:   query,
+		code:    code,
+		useRoot: strings.Contains(query, JQ_VAR_ROOT),
+		timeout: JQ_TIMEOUT,
+	}, nil
+}
+
+// FindResults runs the query against the current element, every output is returned as result
+func (q *jqQuery) FindResults(element ObjectElement) ([]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), q.timeout)
+	defer cancel()
+
+	var root interface{}
+	if q.useRoot {
+		root = element.jqRoot.normalizedValue(element.Root)
+	}
+
+	ret := []interface{}{}
+	iter := q.code.RunWithContext(ctx, element.jqValue.normalizedValue(element.Value), root)
+	for {
+		val, ok := iter.Next()
+		if !ok {
+			break
+		}
+
+		if err, ok := val.(error); ok {
+			return nil, fmt.Errorf(`unable to run jq query "%s": %w`, q.query, err)
+		}
+
+		switch v := val.(type) {
+		case int:
+			ret = append(ret, int64(v))
+		case *big.Int:
+			f, _ := new(big.Float).SetInt(v).Float64()
+			ret = append(ret, f)
+		default:
+			ret = append(ret, v)
+		}
+	}
+
+	return ret, nil
+}
+
+// jqNormalize converts Kubernetes object values into types supported by gojq
+func jqNormalize(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			ret[key] = jqNormalize(item)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for num, item := range v {
+			ret[num] = jqNormalize(item)
+		}
+		return ret
+	case int64:
+		return int(v)
+	case int32:
+		return int(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	default:
+		return v
+	}
+}
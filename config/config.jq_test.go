@@ -0,0 +1,147 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJqFindResults(t *testing.T) {
+	object := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "app",
+		},
+		"spec": map[string]interface{}{
+			"replicas":   int64(3),
+			"containers": []interface{}{map[string]interface{}{"name": "nginx"}, map[string]interface{}{"name": "envoy"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []interface{}
+		error    string
+	}{
+		{name: "int", query: ".spec.replicas", expected: []interface{}{int64(3)}},
+		{name: "int arithmetic", query: ".spec.replicas * 2", expected: []interface{}{int64(6)}},
+		{name: "big int", query: ".spec.replicas * 9223372036854775807", expected: []interface{}{float64(3 * 9223372036854775807.0)}},
+		{name: "float", query: ".spec.replicas / 2", expected: []interface{}{1.5}},
+		{name: "string", query: ".metadata.name", expected: []interface{}{"app"}},
+		{name: "multiple outputs", query: ".spec.containers[].name", expected: []interface{}{"nginx", "envoy"}},
+		{name: "no output", query: "empty", expected: []interface{}{}},
+		{name: "missing", query: ".spec.missing", expected: []interface{}{nil}},
+		{name: "root", query: "$root.metadata.name", expected: []interface{}{"app"}},
+		{name: "error", query: `error("failed")`, error: `unable to run jq query "error("failed")": error: failed`},
+		{name: "type error", query: ".metadata.name | keys", error: `unable to run jq query`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := compileJqQuery(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := query.FindResults(NewObjectElement(object))
+			switch {
+			case test.error == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q, got %v", test.error, results)
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			case test.error == "" && !reflect.DeepEqual(results, test.expected):
+				t.Errorf("expected %#v, got %#v", test.expected, results)
+			}
+		})
+	}
+}
+
+func TestJqCompile(t *testing.T) {
+	tests := []struct {
+		query string
+		error string
+	}{
+		{query: ".spec.replicas"},
+		{query: "$root.spec.replicas"},
+		{query: ".spec.[", error: `unable to parse jq query ".spec.["`},
+		{query: "$other.spec.replicas", error: `unable to compile jq query "$other.spec.replicas"`},
+		{query: "undefined(1)", error: `unable to compile jq query "undefined(1)"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			_, err := compileJqQuery(test.query)
+			switch {
+			case test.error == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q", test.error)
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			}
+		})
+	}
+}
+
+func TestJqForeachRoot(t *testing.T) {
+	object := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "app",
+		},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "nginx"}, map[string]interface{}{"name": "envoy"}},
+		},
+	}
+
+	metric := &ConfigMetric{Foreach: &ConfigMetricForeach{Path: ".spec.containers"}}
+	if err := metric.Foreach.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	elements, err := metric.Elements(NewObjectElement(object))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query, err := compileJqQuery(`$root.metadata.name + "/" + .name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := []interface{}{}
+	for _, element := range elements {
+		elementResults, err := query.FindResults(element)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, elementResults...)
+	}
+
+	expected := []interface{}{"app/nginx", "app/envoy"}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("expected %v, got %v", expected, results)
+	}
+}
+
+func TestJqTimeout(t *testing.T) {
+	query, err := compileJqQuery("last(repeat(1))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	query.timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err = query.FindResults(NewObjectElement(map[string]interface{}{}))
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if !strings.Contains(err.Error(), "context deadline exceeded") {
+		t.Fatalf("expected timeout error, got %q", err.Error())
+	}
+	if duration := time.Since(start); duration > 5*time.Second {
+		t.Fatalf("query was not stopped after timeout, took %v", duration)
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+type (
+	ConfigMetricLabelsFrom struct {
+		Path   string `yaml:"jsonPath" json:"jsonPath"`
+		_path  *objectPath
+		Prefix string `yaml:"prefix" json:"prefix"`
+
+		Allow []*ConfigMetricLabelsFromKey `yaml:"allow" json:"allow"`
+	}
+
+	ConfigMetricLabelsFromKey struct {
+		Key    string `yaml:"key" json:"key"`
+		Regex  string `yaml:"regex" json:"regex"`
+		_regex *regexp.Regexp
+
+		// label name, defaults to sanitized key
+		Label      string `yaml:"label" json:"label"`
+		_labelName string
+	}
+)
+
+var (
+	labelNameSanitizeRegexp = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+// UnmarshalJSON allows allowlist entries to be defined as plain key string or as object
+func (m *ConfigMetricLabelsFromKey) UnmarshalJSON(data []byte) error {
+	type configMetricLabelsFromKey ConfigMetricLabelsFromKey
+	return unmarshalStringOrObject(data, &m.Key, (*configMetricLabelsFromKey)(m))
+}
+
+func (m *ConfigMetricLabelsFrom) Compile() error {
+	if m.Path == "" {
+		return fmt.Errorf(`jsonPath must be set for labelsFrom`)
+	}
+
+	path, err := compileObjectPath(m.Path)
+	if err != nil {
+		return err
+	}
+	m._path = path
+
+	if len(m.Allow) == 0 {
+		return fmt.Errorf(`allow list must be set for labelsFrom "%s"`, m.Path)
+	}
+
+	for _, allowConfig := range m.Allow {
+		if allowConfig == nil || (allowConfig.Key == "") == (allowConfig.Regex == "") {
+			return fmt.Errorf(`either key or regex must be set for labelsFrom "%s"`, m.Path)
+		}
+
+		labelName := allowConfig.Label
+		if allowConfig.Regex != "" {
+			if labelName == "" {
+				return fmt.Errorf(`label must be set for regex "%s" of labelsFrom "%s"`, allowConfig.Regex, m.Path)
+			}
+
+			keyRegex, err := regexp.Compile(allowConfig.Regex)
+			if err != nil {
+				return err
+			}
+			allowConfig._regex = keyRegex
+		}
+
+		if labelName == "" {
+			labelName = SanitizeLabelName(allowConfig.Key)
+		}
+
+		allowConfig._labelName = m.Prefix + labelName
+		if !labelNameRegexp.MatchString(allowConfig._labelName) {
+			return fmt.Errorf(`label name "%s" of labelsFrom "%s" is not a valid Prometheus label name`, allowConfig._labelName, m.Path)
+		}
+	}
+
+	return nil
+}
+
+// LabelNames returns the label names generated by labelsFrom
+func (m *ConfigMetricLabelsFrom) LabelNames() []string {
+	ret := []string{}
+	for _, allowConfig := range m.Allow {
+		ret = append(ret, allowConfig._labelName)
+	}
+
+	return ret
+}
+
+// FindLabels returns the labels generated from the allowlisted map keys of the element,
+// keys which are not found result in empty labels
+func (m *ConfigMetricLabelsFrom) FindLabels(element ObjectElement) (map[string]string, error) {
+	ret := map[string]string{}
+	for _, labelName := range m.LabelNames() {
+		ret[labelName] = ""
+	}
+
+	results, err := m._path.FindResults(element)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) != 1 {
+		return ret, nil
+	}
+
+	source, ok := results[0].(map[string]interface{})
+	if !ok {
+		return ret, nil
+	}
+
+	keys := []string{}
+	for key := range source {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, allowConfig := range m.Allow {
+		if allowConfig._regex == nil {
+			if val, exists := source[allowConfig.Key]; exists {
+				ret[allowConfig._labelName] = labelsFromValue(val)
+			}
+			continue
+		}
+
+		// first matching key wins
+		for _, key := range keys {
+			if allowConfig._regex.MatchString(key) {
+				ret[allowConfig._labelName] = labelsFromValue(source[key])
+				break
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// SanitizeLabelName converts Kubernetes keys (eg. app.kubernetes.io/name) into valid Prometheus label names
+func SanitizeLabelName(val string) string {
+	ret := labelNameSanitizeRegexp.ReplaceAllString(val, "_")
+	if ret == "" || (ret[0] >= '0' && ret[0] <= '9') {
+		ret = "_" + ret
+	}
+
+	return ret
+}
+
+func labelsFromValue(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	MANAGEDFIELDS_FIELD_TIME      = "time"
+	MANAGEDFIELDS_FIELD_MANAGER   = "manager"
+	MANAGEDFIELDS_FIELD_OPERATION = "operation"
+)
+
+type (
+	// ConfigMetricManagedFields finds the latest metadata.managedFields entry owning the field path
+	ConfigMetricManagedFields struct {
+		// field path, eg. .spec.replicas or .data.tls\.crt
+		Path       string `yaml:"path" json:"path"`
+		_fieldPath []string
+
+		// time (default, unix timestamp), manager or operation
+		Field  string `yaml:"field" json:"field"`
+		_field string
+	}
+)
+
+var (
+	// splits field paths at dots which are not escaped
+	managedFieldsPathRegexp = regexp.MustCompile(`(?:\\.|[^.])+`)
+)
+
+func (m *ConfigMetricManagedFields) Compile() error {
+	path := strings.TrimPrefix(strings.TrimSpace(m.Path), ".")
+	if path == "" {
+		return fmt.Errorf(`path is required for managedFields`)
+	}
+
+	m._fieldPath = []string{}
+	for _, part := range managedFieldsPathRegexp.FindAllString(path, -1) {
+		m._fieldPath = append(m._fieldPath, "f:"+strings.ReplaceAll(part, `\.`, "."))
+	}
+
+	m._field = MANAGEDFIELDS_FIELD_TIME
+	if m.Field != "" {
+		m._field = strings.ToLower(m.Field)
+	}
+
+	switch m._field {
+	case MANAGEDFIELDS_FIELD_TIME, MANAGEDFIELDS_FIELD_MANAGER, MANAGEDFIELDS_FIELD_OPERATION:
+	default:
+		return fmt.Errorf(`managedFields field "%s" not supported (time, manager, operation)`, m.Field)
+	}
+
+	return nil
+}
+
+// FindResults returns the configured field of the latest managedFields entry owning the field path,
+// managedFields are always read from the object (also inside foreach)
+func (m *ConfigMetricManagedFields) FindResults(element ObjectElement) ([]interface{}, error) {
+	metadata, _ := element.Root["metadata"].(map[string]interface{})
+	entries, _ := metadata["managedFields"].([]interface{})
+
+	var latestEntry map[string]interface{}
+	var latestTime time.Time
+	for _, row := range entries {
+		entry, ok := row.(map[string]interface{})
+		if !ok || !m.ownsField(entry) {
+			continue
+		}
+
+		timeString, _ := entry["time"].(string)
+		entryTime, err := time.Parse(time.RFC3339, timeString)
+		if err != nil {
+			continue
+		}
+
+		if latestEntry == nil || entryTime.After(latestTime) {
+			latestEntry = entry
+			latestTime = entryTime
+		}
+	}
+
+	if latestEntry == nil {
+		return nil, nil
+	}
+
+	switch m._field {
+	case MANAGEDFIELDS_FIELD_MANAGER, MANAGEDFIELDS_FIELD_OPERATION:
+		return []interface{}{latestEntry[m._field]}, nil
+	}
+
+	return []interface{}{latestTime.Unix()}, nil
+}
+
+// ownsField returns true if the fieldsV1 of the entry contain the field path
+func (m *ConfigMetricManagedFields) ownsField(entry map[string]interface{}) bool {
+	fields, ok := entry["fieldsV1"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for _, part := range m._fieldPath {
+		if fields, ok = fields[part].(map[string]interface{}); !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestManagedFieldsCompile(t *testing.T) {
+	tests := []struct {
+		path      string
+		field     string
+		fieldPath []string
+		error     string
+	}{
+		{path: ".spec.replicas", fieldPath: []string{"f:spec", "f:replicas"}},
+		{path: "spec.replicas", fieldPath: []string{"f:spec", "f:replicas"}},
+		{path: ` .data.tls\.crt `, fieldPath: []string{"f:data", "f:tls.crt"}},
+		{path: `.metadata.annotations.example\.com/owner`, fieldPath: []string{"f:metadata", "f:annotations", "f:example.com/owner"}},
+		{path: `.metadata.labels.app\.kubernetes\.io/name`, fieldPath: []string{"f:metadata", "f:labels", "f:app.kubernetes.io/name"}},
+		{path: ".spec.replicas", field: "Manager", fieldPath: []string{"f:spec", "f:replicas"}},
+		{path: ".spec.replicas", field: "operation", fieldPath: []string{"f:spec", "f:replicas"}},
+		{path: ".spec.replicas", field: "subresource", error: `managedFields field "subresource" not supported`},
+		{path: ".", error: `path is required for managedFields`},
+		{path: "", error: `path is required for managedFields`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path+"/"+test.field, func(t *testing.T) {
+			config := &ConfigMetricManagedFields{Path: test.path, Field: test.field}
+
+			err := config.Compile()
+			switch {
+			case test.error == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q", test.error)
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			case test.error != "":
+				return
+			}
+
+			if !reflect.DeepEqual(config._fieldPath, test.fieldPath) {
+				t.Errorf("expected field path %v, got %v", test.fieldPath, config._fieldPath)
+			}
+		})
+	}
+}
+
+func TestManagedFieldsFindResults(t *testing.T) {
+	object := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"managedFields": []interface{}{
+				map[string]interface{}{
+					"manager":   "kubectl-client-side-apply",
+					"operation": "Update",
+					"time":      "2024-01-01T10:00:00Z",
+					"fieldsV1": map[string]interface{}{
+						"f:spec": map[string]interface{}{
+							"f:replicas": map[string]interface{}{},
+						},
+						"f:data": map[string]interface{}{
+							"f:tls.crt": map[string]interface{}{},
+						},
+					},
+				},
+				map[string]interface{}{
+					"manager":   "kube-controller-manager",
+					"operation": "Update",
+					"time":      "2024-03-01T10:00:00Z",
+					"fieldsV1": map[string]interface{}{
+						"f:status": map[string]interface{}{
+							"f:replicas": map[string]interface{}{},
+						},
+					},
+				},
+				map[string]interface{}{
+					"manager":   "hpa",
+					"operation": "Apply",
+					"time":      "2024-02-01T10:00:00Z",
+					"fieldsV1": map[string]interface{}{
+						"f:spec": map[string]interface{}{
+							"f:replicas": map[string]interface{}{},
+						},
+					},
+				},
+				map[string]interface{}{
+					"manager":   "invalid-time",
+					"operation": "Update",
+					"time":      "yesterday",
+					"fieldsV1": map[string]interface{}{
+						"f:spec": map[string]interface{}{
+							"f:replicas": map[string]interface{}{},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		field    string
+		expected []interface{}
+	}{
+		{name: "latest time", path: ".spec.replicas", expected: []interface{}{int64(1706781600)}},
+		{name: "latest manager", path: ".spec.replicas", field: "manager", expected: []interface{}{"hpa"}},
+		{name: "latest operation", path: ".spec.replicas", field: "operation", expected: []interface{}{"Apply"}},
+		{name: "single owner", path: ".status.replicas", field: "manager", expected: []interface{}{"kube-controller-manager"}},
+		{name: "escaped dot", path: `.data.tls\.crt`, field: "manager", expected: []interface{}{"kubectl-client-side-apply"}},
+		{name: "unescaped dot", path: ".data.tls.crt", field: "manager"},
+		{name: "parent", path: ".spec", field: "manager", expected: []interface{}{"hpa"}},
+		{name: "not owned", path: ".spec.template", field: "manager"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &ConfigMetricManagedFields{Path: test.path, Field: test.field}
+			if err := config.Compile(); err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := config.FindResults(NewObjectElement(object))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(test.expected) == 0 && len(results) == 0 {
+				return
+			}
+
+			if !reflect.DeepEqual(results, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, results)
+			}
+		})
+	}
+}
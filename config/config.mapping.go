@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+type (
+	// ConfigMetricValueMapping maps string values (eg. status.phase) to numbers
+	ConfigMetricValueMapping struct {
+		// exact matches
+		Values map[string]float64 `yaml:"values"`
+
+		// regex matches, checked in order if no exact match was found
+		Regex []*ConfigMetricValueMappingRegex `yaml:"regex"`
+
+		// value for unknown values, unknown values are parsed as number if not set
+		Default *float64 `yaml:"default"`
+	}
+
+	ConfigMetricValueMappingRegex struct {
+		Regex  string `yaml:"regex"`
+		_regex *regexp.Regexp
+		Value  float64 `yaml:"value"`
+	}
+)
+
+func (m *ConfigMetricValueMapping) Compile() error {
+	if len(m.Values) == 0 && len(m.Regex) == 0 && m.Default == nil {
+		return fmt.Errorf(`mapping must define values, regex or default`)
+	}
+
+	for _, regexConfig := range m.Regex {
+		if regexConfig == nil || regexConfig.Regex == "" {
+			return fmt.Errorf(`empty regex found in mapping`)
+		}
+
+		regex, err := regexp.Compile(regexConfig.Regex)
+		if err != nil {
+			return err
+		}
+		regexConfig._regex = regex
+	}
+
+	return nil
+}
+
+// MapValue maps the value to a number, second return value is false if the value is not mapped
+func (m *ConfigMetricValueMapping) MapValue(val interface{}) (*float64, bool) {
+	var key string
+	switch v := val.(type) {
+	case float64:
+		key = strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		key = strconv.FormatInt(v, 10)
+	case uint64:
+		key = strconv.FormatUint(v, 10)
+	case string:
+		key = v
+	case bool:
+		key = strconv.FormatBool(v)
+	default:
+		return nil, false
+	}
+
+	if mappedVal, exists := m.Values[key]; exists {
+		return &mappedVal, true
+	}
+
+	for _, regexConfig := range m.Regex {
+		if regexConfig._regex.MatchString(key) {
+			mappedVal := regexConfig.Value
+			return &mappedVal, true
+		}
+	}
+
+	if m.Default != nil {
+		mappedVal := *m.Default
+		return &mappedVal, true
+	}
+
+	return nil, false
+}
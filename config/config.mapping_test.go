@@ -0,0 +1,113 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	yaml "github.com/goccy/go-yaml"
+)
+
+func TestValueMapping(t *testing.T) {
+	mapping := `
+name: kube_test
+value:
+  jsonPath: .status.phase
+  mapping:
+    values:
+      Running: 1
+      Pending: 2
+      "true": 10
+      "3": 30
+    regex:
+      - regex: ^Fail
+        value: -1
+      - regex: ^F
+        value: -2
+`
+	mappingWithDefault := mapping + `
+    default: 0
+`
+
+	tests := []struct {
+		name     string
+		raw      string
+		phase    interface{}
+		expected *float64
+	}{
+		{name: "exact", raw: mapping, phase: "Pending", expected: floatPtr(2)},
+		{name: "exact is case sensitive", raw: mapping, phase: "running"},
+		{name: "exact before regex", raw: mapping, phase: "Running", expected: floatPtr(1)},
+		{name: "regex", raw: mapping, phase: "Failed", expected: floatPtr(-1)},
+		{name: "regex in order", raw: mapping, phase: "Finished", expected: floatPtr(-2)},
+		{name: "bool", raw: mapping, phase: true, expected: floatPtr(10)},
+		{name: "int", raw: mapping, phase: int64(3), expected: floatPtr(30)},
+		{name: "float", raw: mapping, phase: float64(3), expected: floatPtr(30)},
+		{name: "numeric fallback", raw: mapping, phase: "42", expected: floatPtr(42)},
+		{name: "numeric fallback int", raw: mapping, phase: int64(5), expected: floatPtr(5)},
+		{name: "unknown", raw: mapping, phase: "Unknown"},
+		{name: "default", raw: mappingWithDefault, phase: "Unknown", expected: floatPtr(0)},
+		{name: "default before numeric fallback", raw: mappingWithDefault, phase: "42", expected: floatPtr(0)},
+		{name: "default exact", raw: mappingWithDefault, phase: "Pending", expected: floatPtr(2)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metric := &ConfigMetric{}
+			if err := yaml.UnmarshalWithOptions([]byte(test.raw), metric, yaml.Strict(), yaml.UseJSONUnmarshaler()); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := metric.Compile(); err != nil {
+				t.Fatal(err)
+			}
+
+			object := map[string]interface{}{
+				"status": map[string]interface{}{
+					"phase": test.phase,
+				},
+			}
+
+			val, err := metric.Value.FindValue(NewObjectElement(object))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			switch {
+			case test.expected == nil && val != nil:
+				t.Errorf("expected no value, got %v", *val)
+			case test.expected != nil && val == nil:
+				t.Errorf("expected %v, got no value", *test.expected)
+			case test.expected != nil && *val != *test.expected:
+				t.Errorf("expected %v, got %v", *test.expected, *val)
+			}
+		})
+	}
+}
+
+func TestValueMappingCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping *ConfigMetricValueMapping
+		error   string
+	}{
+		{name: "values", mapping: &ConfigMetricValueMapping{Values: map[string]float64{"Running": 1}}},
+		{name: "default", mapping: &ConfigMetricValueMapping{Default: floatPtr(0)}},
+		{name: "empty", mapping: &ConfigMetricValueMapping{}, error: `mapping must define values, regex or default`},
+		{name: "empty regex", mapping: &ConfigMetricValueMapping{Regex: []*ConfigMetricValueMappingRegex{{Value: 1}}}, error: `empty regex found in mapping`},
+		{name: "invalid regex", mapping: &ConfigMetricValueMapping{Regex: []*ConfigMetricValueMappingRegex{{Regex: "(", Value: 1}}}, error: `missing closing )`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.mapping.Compile()
+			switch {
+			case test.error == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q", test.error)
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	OPERATION_MULTIPLY = "multiply"
+	OPERATION_DIVIDE   = "divide"
+	OPERATION_OFFSET   = "offset"
+	OPERATION_ABS      = "abs"
+	OPERATION_CLAMP    = "clamp"
+	OPERATION_ROUND    = "round"
+	OPERATION_FLOOR    = "floor"
+	OPERATION_CEIL     = "ceil"
+	OPERATION_INVERT   = "invert"
+)
+
+type (
+	// ConfigMetricValueOperation is a numeric operation applied to the value after conversion,
+	// defined as plain method name or as object with parameters
+	ConfigMetricValueOperation struct {
+		Method string `yaml:"method" json:"method"`
+
+		// operand (multiply, divide, offset), either static or from the object
+		Value *float64 `yaml:"value" json:"value"`
+		Path  string   `yaml:"jsonPath" json:"jsonPath"`
+		_path *objectPath
+
+		// clamp
+		Min *float64 `yaml:"min" json:"min"`
+		Max *float64 `yaml:"max" json:"max"`
+
+		// round
+		Precision int `yaml:"precision" json:"precision"`
+
+		_method string
+	}
+)
+
+var (
+	// ErrDivisionByZero is returned if a divide operation has a zero divisor, the value is not used
+	ErrDivisionByZero = errors.New("division by zero")
+
+	// supported parameters of each operation
+	operationParameters = map[string][]string{
+		OPERATION_MULTIPLY: {"value", "jsonPath"},
+		OPERATION_DIVIDE:   {"value", "jsonPath"},
+		OPERATION_OFFSET:   {"value", "jsonPath"},
+		OPERATION_ABS:      {},
+		OPERATION_CLAMP:    {"min", "max"},
+		OPERATION_ROUND:    {"precision"},
+		OPERATION_FLOOR:    {},
+		OPERATION_CEIL:     {},
+		OPERATION_INVERT:   {},
+	}
+)
+
+// UnmarshalJSON allows operations to be defined as plain method name or as object with parameters
+func (m *ConfigMetricValueOperation) UnmarshalJSON(data []byte) error {
+	type configMetricValueOperation ConfigMetricValueOperation
+	return unmarshalStringOrObject(data, &m.Method, (*configMetricValueOperation)(m))
+}
+
+func (m *ConfigMetricValue) compileOperations() error {
+	// relative time values are calculated when metrics are served, operations would be applied to the timestamp
+	if len(m.Operations) > 0 && m._relativeTime != "" {
+		return fmt.Errorf(`operations can not be combined with conversion "%s"`, m._relativeTime)
+	}
+
+	for _, operation := range m.Operations {
+		if operation == nil || operation.Method == "" {
+			return fmt.Errorf(`empty operation found`)
+		}
+
+		if err := operation.compile(); err != nil {
+			return fmt.Errorf(`operation "%s": %w`, operation.Method, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *ConfigMetricValueOperation) compile() error {
+	m._method = strings.ToLower(m.Method)
+
+	supportedParameters, exists := operationParameters[m._method]
+	if !exists {
+		return fmt.Errorf(`operation not supported`)
+	}
+
+	for _, parameter := range m.parameters() {
+		if !slices.Contains(supportedParameters, parameter) {
+			return fmt.Errorf(`parameter "%s" is not supported`, parameter)
+		}
+	}
+
+	switch m._method {
+	case OPERATION_MULTIPLY, OPERATION_DIVIDE, OPERATION_OFFSET:
+		if (m.Value == nil) == (m.Path == "") {
+			return fmt.Errorf(`either value or jsonPath must be set`)
+		}
+
+		if m._method == OPERATION_DIVIDE && m.Value != nil && *m.Value == 0 {
+			return ErrDivisionByZero
+		}
+
+		if m.Path != "" {
+			path, err := compileObjectPath(m.Path)
+			if err != nil {
+				return err
+			}
+			m._path = path
+		}
+
+	case OPERATION_CLAMP:
+		if m.Min == nil && m.Max == nil {
+			return fmt.Errorf(`min or max must be set`)
+		}
+
+		if m.Min != nil && m.Max != nil && *m.Min > *m.Max {
+			return fmt.Errorf(`min must not be greater than max`)
+		}
+
+	case OPERATION_ROUND:
+		if m.Precision < 0 {
+			return fmt.Errorf(`precision must not be negative`)
+		}
+	}
+
+	return nil
+}
+
+// parameters returns the names of the configured parameters
+func (m *ConfigMetricValueOperation) parameters() (ret []string) {
+	if m.Value != nil {
+		ret = append(ret, "value")
+	}
+	if m.Path != "" {
+		ret = append(ret, "jsonPath")
+	}
+	if m.Min != nil {
+		ret = append(ret, "min")
+	}
+	if m.Max != nil {
+		ret = append(ret, "max")
+	}
+	if m.Precision != 0 {
+		ret = append(ret, "precision")
+	}
+
+	return
+}
+
+// parseValue parses the result as value, mapping is applied before the conversions
+func (m *ConfigMetricValue) parseValue(val interface{}) (*float64, error) {
+	if m.Mapping != nil {
+		if ret, _ := m.Mapping.MapValue(val); ret != nil {
+			return ret, nil
+		}
+	}
+
+	return m.ParseValue(val)
+}
+
+// DoOperations applies the numeric operations to the value, returns nil if an operand was not found
+func (m *ConfigMetricValue) DoOperations(element ObjectElement, val float64) (*float64, error) {
+	for _, operation := range m.Operations {
+		switch operation._method {
+		case OPERATION_MULTIPLY, OPERATION_DIVIDE, OPERATION_OFFSET:
+			operand, err := operation.operand(element)
+			if err != nil || operand == nil {
+				return nil, err
+			}
+
+			switch operation._method {
+			case OPERATION_MULTIPLY:
+				val *= *operand
+			case OPERATION_DIVIDE:
+				if *operand == 0 {
+					return nil, ErrDivisionByZero
+				}
+				val /= *operand
+			case OPERATION_OFFSET:
+				val += *operand
+			}
+
+		case OPERATION_ABS:
+			val = math.Abs(val)
+
+		case OPERATION_CLAMP:
+			if operation.Min != nil {
+				val = math.Max(val, *operation.Min)
+			}
+			if operation.Max != nil {
+				val = math.Min(val, *operation.Max)
+			}
+
+		case OPERATION_ROUND:
+			factor := math.Pow(10, float64(operation.Precision))
+			val = math.Round(val*factor) / factor
+
+		case OPERATION_FLOOR:
+			val = math.Floor(val)
+
+		case OPERATION_CEIL:
+			val = math.Ceil(val)
+
+		case OPERATION_INVERT:
+			if val == 0 {
+				val = 1
+			} else {
+				val = 0
+			}
+		}
+	}
+
+	return &val, nil
+}
+
+// operand returns the static operand or the operand found in the element, nil if not found
+func (m *ConfigMetricValueOperation) operand(element ObjectElement) (*float64, error) {
+	if m._path == nil {
+		return m.Value, nil
+	}
+
+	results, err := m._path.FindResults(element)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) != 1 {
+		return nil, nil
+	}
+
+	switch v := results[0].(type) {
+	case float64:
+		return &v, nil
+	case int64:
+		val := float64(v)
+		return &val, nil
+	case string:
+		if val, err := strconv.ParseFloat(v, 64); err == nil {
+			return &val, nil
+		}
+	}
+
+	return nil, nil
+}
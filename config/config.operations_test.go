@@ -0,0 +1,131 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	yaml "github.com/goccy/go-yaml"
+)
+
+func TestValueOperations(t *testing.T) {
+	object := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(4),
+			"containers": []interface{}{
+				map[string]interface{}{"memory": "1Gi"},
+				map[string]interface{}{"memory": "512Mi"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		raw      string
+		expected *float64
+		error    string
+	}{
+		{
+			name: "single value",
+			raw: `
+jsonPath: .spec.replicas
+operations:
+  - method: multiply
+    value: 2
+  - method: offset
+    value: -1
+`,
+			expected: floatPtr(7),
+		},
+		{
+			name: "applied after aggregation",
+			raw: `
+jsonPath: .spec.containers[*].memory
+convert: [toBytes]
+aggregate: sum
+operations:
+  - method: divide
+    value: 1073741824
+`,
+			expected: floatPtr(1.5),
+		},
+		{
+			name: "clamp after aggregation",
+			raw: `
+jsonPath: .spec.containers[*].memory
+convert: [toBytes]
+aggregate: avg
+operations:
+  - method: clamp
+    max: 1
+`,
+			expected: floatPtr(1),
+		},
+		{
+			name: "count",
+			raw: `
+jsonPath: .spec.containers[*]
+aggregate: count
+operations:
+  - method: divide
+    jsonPath: $.spec.replicas
+`,
+			expected: floatPtr(0.5),
+		},
+		{
+			name: "mode",
+			raw: `
+jsonPath: .spec.containers
+mode: length
+operations:
+  - method: multiply
+    value: 10
+`,
+			expected: floatPtr(20),
+		},
+		{
+			name: "relative time",
+			raw: `
+jsonPath: .metadata.creationTimestamp
+convert: [age]
+operations:
+  - method: divide
+    value: 3600
+`,
+			error: `operations can not be combined with conversion "age"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metric := &ConfigMetric{Name: "kube_test", Value: &ConfigMetricValue{}}
+			if err := yaml.UnmarshalWithOptions([]byte(test.raw), metric.Value, yaml.Strict(), yaml.UseJSONUnmarshaler()); err != nil {
+				t.Fatal(err)
+			}
+
+			err := metric.Compile()
+			switch {
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q", test.error)
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			case test.error != "":
+				return
+			case err != nil:
+				t.Fatal(err)
+			}
+
+			val, err := metric.Value.FindValue(NewObjectElement(object))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if val == nil || *val != *test.expected {
+				t.Errorf("expected %v, got %v", *test.expected, val)
+			}
+		})
+	}
+}
+
+func floatPtr(val float64) *float64 {
+	return &val
+}
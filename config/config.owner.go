@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	OWNER_FIELD_NAME = "name"
+	OWNER_FIELD_KIND = "kind"
+
+	// OWNER_MAX_DEPTH limits the owner chain if the root owner is used
+	OWNER_MAX_DEPTH = 10
+)
+
+type (
+	// ConfigMetricLabelOwner uses the owner found by following the controller ownerReferences as label
+	ConfigMetricLabelOwner struct {
+		// number of controller ownerReferences to follow (1 = direct owner), root owner if not set
+		Depth *int `yaml:"depth"`
+
+		// name (default) or kind
+		Field  string `yaml:"field"`
+		_field string
+	}
+)
+
+func (m *ConfigMetricLabelOwner) Compile() error {
+	if m.Depth != nil && (*m.Depth < 1 || *m.Depth > OWNER_MAX_DEPTH) {
+		return fmt.Errorf(`owner depth must be between 1 and %d`, OWNER_MAX_DEPTH)
+	}
+
+	m._field = OWNER_FIELD_NAME
+	if m.Field != "" {
+		m._field = strings.ToLower(m.Field)
+	}
+
+	switch m._field {
+	case OWNER_FIELD_NAME, OWNER_FIELD_KIND:
+	default:
+		return fmt.Errorf(`owner field "%s" not supported (name, kind)`, m.Field)
+	}
+
+	return nil
+}
+
+// MaxDepth returns the number of controller ownerReferences to follow
+func (m *ConfigMetricLabelOwner) MaxDepth() int {
+	if m.Depth == nil {
+		return OWNER_MAX_DEPTH
+	}
+
+	return *m.Depth
+}
+
+// OwnerLabel returns the (converted) label value for the resolved owner
+func (m *ConfigMetricLabel) OwnerLabel(kind, name string) (string, error) {
+	if m.Owner._field == OWNER_FIELD_KIND {
+		return m.DoConvertLabel(kind)
+	}
+
+	return m.DoConvertLabel(name)
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	METRIC_MODE_REFERENCE = "reference"
+
+	REFERENCE_LABEL_NAME      = "reference_name"
+	REFERENCE_LABEL_NAMESPACE = "reference_namespace"
+)
+
+type (
+	// ConfigMetricReference emits one series per referenced object (1 if the target exists, otherwise 0)
+	ConfigMetricReference struct {
+		// target resource
+		*schema.GroupVersionResource `yaml:",inline"`
+
+		// references (eg. .spec.tls[*].secretName or .subjects[?(@.kind=="ServiceAccount")])
+		Path string `yaml:"jsonPath"`
+
+		// name of the target relative to the reference, optional (default: the reference itself)
+		NamePath  string `yaml:"namePath"`
+		_namePath *objectPath
+
+		// namespace of the target relative to the reference, optional (default: namespace of the object)
+		NamespacePath  string `yaml:"namespacePath"`
+		_namespacePath *objectPath
+
+		// target is not namespaced
+		ClusterScoped bool `yaml:"clusterScoped"`
+	}
+)
+
+// compileReference configures the metric to emit one series per reference
+func (m *ConfigMetric) compileReference() error {
+	if m.Reference == nil {
+		return fmt.Errorf(`reference is required for mode "%s"`, METRIC_MODE_REFERENCE)
+	}
+
+	if m.Foreach != nil || m.Value != nil {
+		return fmt.Errorf(`foreach and value are not supported for mode "%s"`, METRIC_MODE_REFERENCE)
+	}
+
+	for _, labelName := range []string{REFERENCE_LABEL_NAME, REFERENCE_LABEL_NAMESPACE} {
+		if _, exists := m.Labels[labelName]; exists {
+			return fmt.Errorf(`label "%s" is reserved for mode "%s"`, labelName, METRIC_MODE_REFERENCE)
+		}
+	}
+
+	if err := m.Reference.Compile(); err != nil {
+		return err
+	}
+
+	m.Foreach = &ConfigMetricForeach{Path: m.Reference.Path}
+
+	// value is set by the existence of the target
+	m.Value = &ConfigMetricValue{}
+
+	return nil
+}
+
+func (m *ConfigMetricReference) Compile() error {
+	if m.GroupVersionResource == nil || m.Version == "" || m.Resource == "" {
+		return fmt.Errorf("version and resource of the reference target are required")
+	}
+
+	if m.Path == "" {
+		return fmt.Errorf("jsonPath of reference is required")
+	}
+
+	if m.ClusterScoped && m.NamespacePath != "" {
+		return fmt.Errorf("namespacePath can not be used for clusterScoped reference targets")
+	}
+
+	path, err := compileObjectPath(m.NamePath)
+	if err != nil {
+		return err
+	}
+	m._namePath = path
+
+	if m.NamespacePath != "" {
+		path, err := compileObjectPath(m.NamespacePath)
+		if err != nil {
+			return err
+		}
+		m._namespacePath = path
+	}
+
+	return nil
+}
+
+// Target returns the namespace (empty if clusterScoped) and name of the referenced object,
+// third return value is false if the reference has no name
+func (m *ConfigMetricReference) Target(element ObjectElement) (string, string, bool, error) {
+	name, err := referenceField(m._namePath, element)
+	if err != nil || name == "" {
+		return "", "", false, err
+	}
+
+	if m.ClusterScoped {
+		return "", name, true, nil
+	}
+
+	namespace := ""
+	if m._namespacePath != nil {
+		if namespace, err = referenceField(m._namespacePath, element); err != nil {
+			return "", "", false, err
+		}
+	}
+
+	if namespace == "" {
+		metadata, _ := element.Root["metadata"].(map[string]interface{})
+		namespace, _ = metadata["namespace"].(string)
+	}
+
+	return namespace, name, true, nil
+}
+
+// referenceField returns the string found by the path, empty if not exactly one string was found
+func referenceField(path *objectPath, element ObjectElement) (string, error) {
+	results, err := path.FindResults(element)
+	if err != nil || len(results) != 1 {
+		return "", err
+	}
+
+	ret, _ := results[0].(string)
+	return ret, nil
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	yaml "github.com/goccy/go-yaml"
+)
+
+func TestReferenceTargets(t *testing.T) {
+	serviceAccount := func(namespace, name string) map[string]interface{} {
+		ret := map[string]interface{}{"kind": "ServiceAccount", "name": name}
+		if namespace != "" {
+			ret["namespace"] = namespace
+		}
+		return ret
+	}
+
+	roleBinding := func(subjects ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{"namespace": "default", "name": "binding"},
+			"subjects": subjects,
+		}
+	}
+
+	raw := `
+name: kube_rolebinding_serviceaccount_reference
+mode: reference
+reference:
+  version: v1
+  resource: serviceaccounts
+  jsonPath: .subjects[?(@.kind=="ServiceAccount")]
+  namePath: .name
+  namespacePath: .namespace
+`
+
+	tests := []struct {
+		name    string
+		object  map[string]interface{}
+		targets []string
+	}{
+		{
+			name:    "single subject",
+			object:  roleBinding(serviceAccount("kube-system", "app")),
+			targets: []string{"kube-system/app"},
+		},
+		{
+			name: "multiple subjects",
+			object: roleBinding(
+				serviceAccount("kube-system", "app"),
+				map[string]interface{}{"kind": "User", "name": "admin"},
+				serviceAccount("", "local"),
+			),
+			targets: []string{"kube-system/app", "default/local"},
+		},
+		{
+			name:    "no matching subject",
+			object:  roleBinding(map[string]interface{}{"kind": "User", "name": "admin"}),
+			targets: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metric := &ConfigMetric{}
+			if err := yaml.UnmarshalWithOptions([]byte(raw), metric, yaml.Strict(), yaml.UseJSONUnmarshaler()); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := metric.Compile(); err != nil {
+				t.Fatal(err)
+			}
+
+			elements, err := metric.Elements(NewObjectElement(test.object))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			targets := []string{}
+			for _, element := range elements {
+				namespace, name, found, err := metric.Reference.Target(element)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if found {
+					targets = append(targets, namespace+"/"+name)
+				}
+			}
+
+			if !reflect.DeepEqual(targets, test.targets) {
+				t.Errorf("expected targets %v, got %v", test.targets, targets)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	SEMVER_FIELD_MAJOR      = "major"
+	SEMVER_FIELD_MINOR      = "minor"
+	SEMVER_FIELD_PATCH      = "patch"
+	SEMVER_FIELD_NORMALIZED = "normalized"
+)
+
+func (m *ConfigMetricConvert) compileSemver() error {
+	m._field = SEMVER_FIELD_NORMALIZED
+	if m.Field != "" {
+		m._field = strings.ToLower(m.Field)
+	}
+
+	switch m._field {
+	case SEMVER_FIELD_MAJOR, SEMVER_FIELD_MINOR, SEMVER_FIELD_PATCH, SEMVER_FIELD_NORMALIZED:
+	default:
+		return fmt.Errorf(`field "%s" not supported (major, minor, patch, normalized)`, m.Field)
+	}
+
+	return nil
+}
+
+// convertSemver parses the version and returns the configured field, returns false if the value is not a version
+func (m *ConfigMetricConvert) convertSemver(val string) (string, bool) {
+	version, err := parseSemver(val)
+	if err != nil {
+		return "", false
+	}
+
+	switch m._field {
+	case SEMVER_FIELD_MAJOR:
+		return strconv.FormatUint(version.Major(), 10), true
+	case SEMVER_FIELD_MINOR:
+		return strconv.FormatUint(version.Minor(), 10), true
+	case SEMVER_FIELD_PATCH:
+		return strconv.FormatUint(version.Patch(), 10), true
+	}
+
+	// normalized version without leading "v" and build metadata
+	ret := fmt.Sprintf("%d.%d.%d", version.Major(), version.Minor(), version.Patch())
+	if version.Prerelease() != "" {
+		ret += "-" + version.Prerelease()
+	}
+
+	return ret, true
+}
+
+// parseSemver parses versions, leading "v", missing minor/patch and build metadata are tolerated
+func parseSemver(val string) (*semver.Version, error) {
+	return semver.NewVersion(strings.TrimSpace(val))
+}
+
+func (m *ConfigMetricFilter) compileSemverRange() error {
+	if m.SemverRange == "" {
+		return nil
+	}
+
+	constraints, err := semver.NewConstraint(m.SemverRange)
+	if err != nil {
+		return fmt.Errorf(`invalid semverRange "%s": %w`, m.SemverRange, err)
+	}
+	m._semverRange = constraints
+
+	return nil
+}
+
+// matchSemverRange returns true if the value is a version matching the semverRange
+func (m *ConfigMetricFilter) matchSemverRange(val string) bool {
+	version, err := parseSemver(val)
+	if err != nil {
+		return false
+	}
+
+	return m._semverRange.Check(version)
+}
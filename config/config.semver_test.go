@@ -0,0 +1,70 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestConvertSemver(t *testing.T) {
+	tests := []struct {
+		val      string
+		field    string
+		expected string
+		ok       bool
+	}{
+		{val: "1.2.3", expected: "1.2.3", ok: true},
+		{val: "v1.2.3", expected: "1.2.3", ok: true},
+		{val: "1.2", expected: "1.2.0", ok: true},
+		{val: "v1.29.4-rc.1+build.5", expected: "1.29.4-rc.1", ok: true},
+		{val: "v2.5.1", field: "major", expected: "2", ok: true},
+		{val: "v2.5.1", field: "minor", expected: "5", ok: true},
+		{val: "v2.5.1", field: "Patch", expected: "1", ok: true},
+		{val: "latest", ok: false},
+		{val: "", ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.val+"/"+test.field, func(t *testing.T) {
+			convert := &ConfigMetricConvert{Field: test.field}
+			if err := convert.compileSemver(); err != nil {
+				t.Fatal(err)
+			}
+
+			ret, ok := convert.convertSemver(test.val)
+			if ok != test.ok {
+				t.Fatalf("expected ok=%v, got %v", test.ok, ok)
+			}
+
+			if ret != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, ret)
+			}
+		})
+	}
+}
+
+func TestMatchSemverRange(t *testing.T) {
+	tests := []struct {
+		semverRange string
+		val         string
+		expected    bool
+	}{
+		{semverRange: ">= 1.28", val: "v1.29.4", expected: true},
+		{semverRange: ">= 1.28", val: "v1.27.0", expected: false},
+		{semverRange: "~1.2", val: "1.2.9", expected: true},
+		{semverRange: "~1.2", val: "1.3.0", expected: false},
+		{semverRange: ">= 1.0, < 2.0", val: "2.0.0", expected: false},
+		{semverRange: ">= 1.0", val: "latest", expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.semverRange+"/"+test.val, func(t *testing.T) {
+			filter := &ConfigMetricFilter{SemverRange: test.semverRange}
+			if err := filter.compileSemverRange(); err != nil {
+				t.Fatal(err)
+			}
+
+			if ret := filter.matchSemverRange(test.val); ret != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, ret)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	sprig "github.com/Masterminds/sprig/v3"
+)
+
+type (
+	// TemplateData is passed to value and label templates,
+	// the dot is the value, the other fields are available using template functions
+	TemplateData struct {
+		// Value is the extracted (and converted) value, for metric values a float64 or nil (dot)
+		Value interface{}
+
+		// Object is the whole object (function "object")
+		Object map[string]interface{}
+
+		// Element is the current foreach element, the object without foreach (function "element")
+		Element interface{}
+
+		// Labels are the metric labels before label templates are rendered (function "labels")
+		Labels map[string]string
+	}
+
+	// configTemplate is a parsed template, the template is shared by concurrent collects
+	// so it's rendered using instances which have the template functions bound to their own data
+	configTemplate struct {
+		template  *template.Template
+		instances sync.Pool
+	}
+
+	// templateInstance is a clone of the parsed template, reused for multiple renders
+	templateInstance struct {
+		template *template.Template
+		data     TemplateData
+	}
+)
+
+// FuncMap returns the template functions providing the object context, the data is read when the functions are called
+func (d *TemplateData) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"object": func() map[string]interface{} {
+			return d.Object
+		},
+		"element": func() interface{} {
+			return d.Element
+		},
+		"labels": func() map[string]string {
+			return d.Labels
+		},
+	}
+}
+
+func (m *ConfigMetricJsonPath) compileTemplate() error {
+	if m.Template == nil {
+		return nil
+	}
+
+	tmpl, err := template.New("template").Funcs(sprig.TxtFuncMap()).Funcs((&TemplateData{}).FuncMap()).Parse(*m.Template)
+	if err != nil {
+		return fmt.Errorf("unable to parse template: %w", err)
+	}
+	m._template = &configTemplate{template: tmpl}
+
+	return nil
+}
+
+// HasTemplate returns true if a template is configured
+func (m *ConfigMetricJsonPath) HasTemplate() bool {
+	return m != nil && m._template != nil
+}
+
+// RenderTemplate renders the template with the value as dot
+func (m *ConfigMetricJsonPath) RenderTemplate(data TemplateData) (string, error) {
+	instance, err := m._template.instance()
+	if err != nil {
+		return "", err
+	}
+	defer m._template.release(instance)
+
+	instance.data = data
+
+	buf := new(bytes.Buffer)
+	if err := instance.template.Execute(buf, data.Value); err != nil {
+		return "", fmt.Errorf("unable to execute template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// instance returns an unused instance of the template, instances are only cloned if all are in use
+func (t *configTemplate) instance() (*templateInstance, error) {
+	if instance, ok := t.instances.Get().(*templateInstance); ok {
+		return instance, nil
+	}
+
+	tmpl, err := t.template.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("unable to clone template: %w", err)
+	}
+
+	instance := &templateInstance{}
+	instance.template = tmpl.Funcs(instance.data.FuncMap())
+
+	return instance, nil
+}
+
+// release returns the instance for reuse, the data is cleared to not keep the object
+func (t *configTemplate) release(instance *templateInstance) {
+	instance.data = TemplateData{}
+	t.instances.Put(instance)
+}
+
+// RenderValueTemplate renders the template and parses the result as metric value, empty results are no value
+func (m *ConfigMetricValue) RenderValueTemplate(data TemplateData) (*float64, error) {
+	ret, err := m.RenderTemplate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ret = strings.TrimSpace(ret)
+	if ret == "" {
+		return nil, nil
+	}
+
+	val, err := strconv.ParseFloat(ret, 64)
+	if err != nil {
+		return nil, fmt.Errorf(`template result "%s" is not a number: %w`, ret, err)
+	}
+
+	return &val, nil
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	data := TemplateData{
+		Value: "1c3e5f7a-0000",
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "app"},
+		},
+		Element: map[string]interface{}{"image": "nginx"},
+		Labels:  map[string]string{"namespace": "default"},
+	}
+
+	tests := map[string]string{
+		`{{ . | abbrev 5 }}`: "1c...",
+		`{{ (labels).namespace }}/{{ (object).metadata.name }}`: "default/app",
+		`{{ (element).image }}`:                                 "nginx",
+		`{{ with object }}{{ .metadata.name }}{{ end }}`:        "app",
+	}
+
+	for tmpl, expected := range tests {
+		t.Run(tmpl, func(t *testing.T) {
+			config := &ConfigMetricJsonPath{Template: &tmpl}
+			if err := config.compileTemplate(); err != nil {
+				t.Fatal(err)
+			}
+
+			ret, err := config.RenderTemplate(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ret != expected {
+				t.Errorf("expected %q, got %q", expected, ret)
+			}
+		})
+	}
+}
+
+func TestRenderValueTemplate(t *testing.T) {
+	tmpl := `{{ if (object).metadata.annotations.expiry }}{{ . }}{{ end }}`
+	config := &ConfigMetricValue{ConfigMetricJsonPath: &ConfigMetricJsonPath{Template: &tmpl}}
+	if err := config.compileTemplate(); err != nil {
+		t.Fatal(err)
+	}
+
+	withExpiry := map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]interface{}{"expiry": "soon"}},
+	}
+	withoutExpiry := map[string]interface{}{
+		"metadata": map[string]interface{}{},
+	}
+
+	val, err := config.RenderValueTemplate(TemplateData{Value: float64(42), Object: withExpiry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val == nil || *val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+
+	val, err = config.RenderValueTemplate(TemplateData{Value: float64(42), Object: withoutExpiry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != nil {
+		t.Errorf("expected no value, got %v", *val)
+	}
+}
+
+func TestRenderTemplateConcurrent(t *testing.T) {
+	tmpl := `{{ (object).metadata.name }}/{{ (element).name }}/{{ . }}`
+	config := &ConfigMetricJsonPath{Template: &tmpl}
+	if err := config.compileTemplate(); err != nil {
+		t.Fatal(err)
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("app-%d", i)
+			data := TemplateData{
+				Value:   i,
+				Object:  map[string]interface{}{"metadata": map[string]interface{}{"name": name}},
+				Element: map[string]interface{}{"name": "container"},
+			}
+
+			for j := 0; j < 20; j++ {
+				ret, err := config.RenderTemplate(data)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if expected := fmt.Sprintf("%s/container/%d", name, i); ret != expected {
+					t.Errorf("expected %q, got %q", expected, ret)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// unmarshalStringOrObject decodes a config entry which can be defined as plain string (eg. a method name)
+// or as object with parameters, target must be an alias type of the entry to not recurse into UnmarshalJSON,
+// unknown object fields are rejected
+func unmarshalStringOrObject(data []byte, value *string, target interface{}) error {
+	if err := json.Unmarshal(data, value); err == nil {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(target)
+}
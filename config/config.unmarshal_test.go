@@ -0,0 +1,84 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	yaml "github.com/goccy/go-yaml"
+)
+
+func TestUnmarshalStringOrObject(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		target   interface{}
+		expected interface{}
+		error    string
+	}{
+		{
+			name:     "convert string",
+			raw:      `toTimestamp`,
+			target:   &ConfigMetricConvert{},
+			expected: &ConfigMetricConvert{Method: "toTimestamp"},
+		},
+		{
+			name:     "convert object",
+			raw:      `{method: truncate, length: 5}`,
+			target:   &ConfigMetricConvert{},
+			expected: &ConfigMetricConvert{Method: "truncate", Length: 5},
+		},
+		{
+			name:   "convert unknown field",
+			raw:    `{method: truncate, size: 5}`,
+			target: &ConfigMetricConvert{},
+			error:  `unknown field "size"`,
+		},
+		{
+			name:     "operation string",
+			raw:      `abs`,
+			target:   &ConfigMetricValueOperation{},
+			expected: &ConfigMetricValueOperation{Method: "abs"},
+		},
+		{
+			name:     "operation object",
+			raw:      `{method: divide, value: 1000}`,
+			target:   &ConfigMetricValueOperation{},
+			expected: &ConfigMetricValueOperation{Method: "divide", Value: floatPtr(1000)},
+		},
+		{
+			name:     "labelsFrom key string",
+			raw:      `app.kubernetes.io/name`,
+			target:   &ConfigMetricLabelsFromKey{},
+			expected: &ConfigMetricLabelsFromKey{Key: "app.kubernetes.io/name"},
+		},
+		{
+			name:     "labelsFrom key object",
+			raw:      `{key: example.com/team, label: team}`,
+			target:   &ConfigMetricLabelsFromKey{},
+			expected: &ConfigMetricLabelsFromKey{Key: "example.com/team", Label: "team"},
+		},
+		{
+			name:   "labelsFrom key list",
+			raw:    `[app]`,
+			target: &ConfigMetricLabelsFromKey{},
+			error:  `cannot unmarshal array`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := yaml.UnmarshalWithOptions([]byte(test.raw), test.target, yaml.Strict(), yaml.UseJSONUnmarshaler())
+			switch {
+			case test.error == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q", test.error)
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			case test.error == "" && !reflect.DeepEqual(test.target, test.expected):
+				t.Errorf("expected %+v, got %+v", test.expected, test.target)
+			}
+		})
+	}
+}
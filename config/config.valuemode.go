@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	VALUE_MODE_DEFAULT = ""
+	VALUE_MODE_LENGTH  = "length"
+	VALUE_MODE_EXISTS  = "exists"
+	VALUE_MODE_ISEMPTY = "isempty"
+)
+
+func (m *ConfigMetricValue) compileMode() error {
+	m._mode = strings.ToLower(m.Mode)
+
+	switch m._mode {
+	case VALUE_MODE_DEFAULT:
+		return nil
+	case VALUE_MODE_LENGTH, VALUE_MODE_EXISTS, VALUE_MODE_ISEMPTY:
+	default:
+		return fmt.Errorf(`value mode "%s" not supported`, m.Mode)
+	}
+
+	if m.Path == "" && m.Jq == "" && m.ManagedFields == nil && m.Expression == "" {
+		return fmt.Errorf(`value mode "%s" requires jsonPath, jq, managedFields or expression`, m.Mode)
+	}
+
+	if m.Aggregate != "" || len(m.Convert) > 0 || m.Mapping != nil {
+		return fmt.Errorf(`value mode "%s" can not be used with aggregate, convert or mapping`, m.Mode)
+	}
+
+	return nil
+}
+
+// modeValue returns the structural value of the results (length, exists or isEmpty)
+func (m *ConfigMetricValue) modeValue(results []interface{}) *float64 {
+	ret := float64(0)
+
+	switch m._mode {
+	case VALUE_MODE_LENGTH:
+		if len(results) == 1 && (m._path == nil || !m._path.multi) {
+			// length of single array, map or string
+			switch v := results[0].(type) {
+			case []interface{}:
+				ret = float64(len(v))
+			case map[string]interface{}:
+				ret = float64(len(v))
+			case string:
+				ret = float64(utf8.RuneCountInString(v))
+			case nil:
+				ret = 0
+			default:
+				// scalar value, not countable
+				return nil
+			}
+		} else {
+			// multiple results or path expanding into results (eg. jsonPath with [*])
+			ret = float64(len(results))
+		}
+
+	case VALUE_MODE_EXISTS:
+		for _, result := range results {
+			if result != nil {
+				ret = 1
+				break
+			}
+		}
+
+	case VALUE_MODE_ISEMPTY:
+		ret = 1
+		for _, result := range results {
+			if !isEmptyResult(result) {
+				ret = 0
+				break
+			}
+		}
+	}
+
+	return &ret
+}
+
+// isEmptyResult returns true if the result is nil, an empty string, array or map
+func isEmptyResult(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+
+	return false
+}
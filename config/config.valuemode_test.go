@@ -0,0 +1,136 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestValueModeLength(t *testing.T) {
+	object := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":       "app",
+			"finalizers": []interface{}{"a", "b"},
+			"labels":     map[string]interface{}{"a": "1", "b": "2", "c": "3"},
+		},
+		"spec": map[string]interface{}{
+			"replicas":   int64(3),
+			"containers": []interface{}{map[string]interface{}{"name": "nginx"}},
+		},
+	}
+
+	tests := []valueModeTest{
+		{path: ".metadata.finalizers", expected: floatPtr(2)},
+		{path: ".metadata.labels", expected: floatPtr(3)},
+		{path: ".metadata.name", expected: floatPtr(3)},
+		{path: ".metadata.missing", expected: floatPtr(0)},
+		{path: ".metadata.finalizers[*]", expected: floatPtr(2)},
+		{path: ".spec.containers[*].name", expected: floatPtr(1)},
+		{path: ".spec.containers[*]", expected: floatPtr(1)},
+		{path: `.spec.containers[?(@.name=="nginx")]`, expected: floatPtr(1)},
+		{path: ".spec.volumes[*]", expected: floatPtr(0)},
+		{path: ".spec.replicas", expected: nil},
+	}
+
+	testValueMode(t, VALUE_MODE_LENGTH, object, tests)
+}
+
+func TestValueModeExists(t *testing.T) {
+	object := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":        "app",
+			"annotations": map[string]interface{}{"empty": ""},
+			"finalizers":  []interface{}{},
+		},
+		"spec": map[string]interface{}{
+			"replicas":   int64(0),
+			"paused":     false,
+			"selector":   nil,
+			"containers": []interface{}{map[string]interface{}{"name": "nginx"}, map[string]interface{}{"name": "envoy", "image": "envoy"}},
+		},
+	}
+
+	tests := []valueModeTest{
+		{path: ".metadata.name", expected: floatPtr(1)},
+		{path: ".metadata.missing", expected: floatPtr(0)},
+		{path: ".metadata.labels.app", expected: floatPtr(0)},
+		{path: ".metadata.annotations.empty", expected: floatPtr(1)},
+		{path: ".metadata.finalizers", expected: floatPtr(1)},
+		{path: ".spec.replicas", expected: floatPtr(1)},
+		{path: ".spec.paused", expected: floatPtr(1)},
+		{path: ".spec.selector", expected: floatPtr(0)},
+		{path: ".spec.containers[*].image", expected: floatPtr(1)},
+		{path: `.spec.containers[?(@.name=="nginx")].image`, expected: floatPtr(0)},
+		{path: ".spec.volumes[*]", expected: floatPtr(0)},
+	}
+
+	testValueMode(t, VALUE_MODE_EXISTS, object, tests)
+}
+
+func TestValueModeIsEmpty(t *testing.T) {
+	object := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":        "app",
+			"annotations": map[string]interface{}{"empty": ""},
+			"labels":      map[string]interface{}{},
+			"finalizers":  []interface{}{},
+		},
+		"spec": map[string]interface{}{
+			"replicas":   int64(0),
+			"paused":     false,
+			"containers": []interface{}{map[string]interface{}{"name": "nginx", "args": []interface{}{}}, map[string]interface{}{"name": "envoy", "args": []interface{}{"-c"}}},
+		},
+	}
+
+	tests := []valueModeTest{
+		{path: ".metadata.name", expected: floatPtr(0)},
+		{path: ".metadata.missing", expected: floatPtr(1)},
+		{path: ".metadata.annotations.empty", expected: floatPtr(1)},
+		{path: ".metadata.labels", expected: floatPtr(1)},
+		{path: ".metadata.finalizers", expected: floatPtr(1)},
+		{path: ".metadata.annotations", expected: floatPtr(0)},
+		{path: ".spec.replicas", expected: floatPtr(0)},
+		{path: ".spec.paused", expected: floatPtr(0)},
+		{path: ".spec.containers[*].args", expected: floatPtr(0)},
+		{path: `.spec.containers[?(@.name=="nginx")].args`, expected: floatPtr(1)},
+		{path: ".spec.volumes[*]", expected: floatPtr(1)},
+	}
+
+	testValueMode(t, VALUE_MODE_ISEMPTY, object, tests)
+}
+
+type valueModeTest struct {
+	path     string
+	expected *float64
+}
+
+func testValueMode(t *testing.T, mode string, object map[string]interface{}, tests []valueModeTest) {
+	t.Helper()
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			metric := &ConfigMetric{
+				Name: "kube_test",
+				Value: &ConfigMetricValue{
+					ConfigMetricJsonPath: &ConfigMetricJsonPath{Path: test.path},
+					Mode:                 mode,
+				},
+			}
+			if err := metric.Compile(); err != nil {
+				t.Fatal(err)
+			}
+
+			val, err := metric.Value.FindValue(NewObjectElement(object))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			switch {
+			case test.expected == nil && val != nil:
+				t.Errorf("expected no value, got %v", *val)
+			case test.expected != nil && val == nil:
+				t.Errorf("expected %v, got no value", *test.expected)
+			case test.expected != nil && *val != *test.expected:
+				t.Errorf("expected %v, got %v", *test.expected, *val)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	yaml "github.com/goccy/go-yaml"
+)
+
+func TestConfigCompileBaseLabels(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		error string
+	}{
+		{
+			name: "valid",
+			raw: `
+resources:
+  - version: v1
+    resource: pods
+    metrics:
+      - name: kube_pod_info
+        value:
+          value: 1
+        labels:
+          node:
+            jsonPath: .spec.nodeName
+`,
+		},
+		{
+			name: "label",
+			raw: `
+resources:
+  - version: v1
+    resource: pods
+    metrics:
+      - name: kube_pod_info
+        value:
+          value: 1
+        labels:
+          namespace:
+            jsonPath: .metadata.namespace
+`,
+			error: `label "namespace" of metric "kube_pod_info" is conflicting with base label "namespace"`,
+		},
+		{
+			name: "labelsFrom",
+			raw: `
+resources:
+  - version: v1
+    resource: pods
+    metrics:
+      - name: kube_pod_labels
+        value:
+          value: 1
+        labelsFrom:
+          - jsonPath: .metadata.labels
+            allow: [name]
+`,
+			error: `label "name" of metric "kube_pod_labels" is conflicting with base label "name"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := Config{}
+			if err := yaml.UnmarshalWithOptions([]byte(test.raw), &config, yaml.Strict(), yaml.UseJSONUnmarshaler()); err != nil {
+				t.Fatal(err)
+			}
+
+			err := config.Compile([]string{"gvr", "namespace", "name"})
+			switch {
+			case test.error == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q", test.error)
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			}
+		})
+	}
+}
+
+func TestConfigCompileMetricNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		error string
+	}{
+		{
+			name: "valid",
+			raw: `
+resources:
+  - version: v1
+    resource: pods
+    metrics:
+      - name: kube_pod_info
+        value:
+          value: 1
+  - group: apps
+    version: v1
+    resource: deployments
+    metrics:
+      - name: kube_deployment_info
+        value:
+          value: 1
+`,
+		},
+		{
+			name: "duplicate across resources",
+			raw: `
+resources:
+  - version: v1
+    resource: pods
+    metrics:
+      - name: kube_info
+        value:
+          value: 1
+  - group: apps
+    version: v1
+    resource: deployments
+    metrics:
+      - name: kube_info
+        value:
+          value: 1
+`,
+			error: `metric "kube_info" is defined multiple times`,
+		},
+		{
+			name: "companion",
+			raw: `
+resources:
+  - group: apps
+    version: v1
+    resource: deployments
+    metrics:
+      - name: kube_deployment_condition
+        mode: conditions
+        conditions:
+          generationLag: true
+      - name: kube_deployment_condition_generation_lag
+        value:
+          jsonPath: .metadata.generation
+`,
+			error: `metric "kube_deployment_condition_generation_lag" is defined multiple times`,
+		},
+		{
+			name: "reserved",
+			raw: `
+resources:
+  - version: v1
+    resource: secrets
+    metrics:
+      - name: kube_resource_exporter_conversion_failed
+        value:
+          value: 1
+`,
+			error: `metric name "kube_resource_exporter_conversion_failed" is reserved`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := Config{}
+			if err := yaml.UnmarshalWithOptions([]byte(test.raw), &config, yaml.Strict(), yaml.UseJSONUnmarshaler()); err != nil {
+				t.Fatal(err)
+			}
+
+			err := config.Compile([]string{"gvr", "namespace", "name"})
+			switch {
+			case test.error == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.error != "" && err == nil:
+				t.Fatalf("expected error %q", test.error)
+			case test.error != "" && !strings.Contains(err.Error(), test.error):
+				t.Fatalf("expected error %q, got %q", test.error, err.Error())
+			}
+		})
+	}
+}
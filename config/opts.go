@@ -68,6 +68,25 @@ func (o *Opts) GetCachePath(path string) (ret *string) {
 	return
 }
 
+// BaseLabelNames returns the label names which are added to every resource metric
+func (o *Opts) BaseLabelNames() []string {
+	baseLabels := []string{}
+
+	if o.Metrics.Labels.Gvr != "" {
+		baseLabels = append(baseLabels, o.Metrics.Labels.Gvr)
+	}
+
+	if o.Metrics.Labels.Namespace != "" {
+		baseLabels = append(baseLabels, o.Metrics.Labels.Namespace)
+	}
+
+	if o.Metrics.Labels.Name != "" {
+		baseLabels = append(baseLabels, o.Metrics.Labels.Name)
+	}
+
+	return baseLabels
+}
+
 func (o *Opts) GetJson() []byte {
 	jsonBytes, err := json.Marshal(o)
 	if err != nil {
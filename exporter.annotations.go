@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/webdevops/kube-resource-exporter/config"
+)
+
+const (
+	metricNameAnnotationMetricsInvalid = config.METRIC_NAME_ANNOTATION_METRICS_INVALID
+
+	eventReasonInvalidMetricAnnotation = "InvalidMetricAnnotation"
+)
+
+// registerAnnotationMetrics registers the metrics defined by annotations of existing objects,
+// invalid definitions are ignored here and reported while collecting
+func (m *MetricsCollectorKubeResources) registerAnnotationMetrics(resourceConfig *config.ConfigResource) {
+	logger := m.Logger().With(slog.String("gvr", resourceConfig.GroupVersionResource.String()))
+
+	err := listResourceMetadata(m.Context(), *resourceConfig.GroupVersionResource, "", resourceConfig.KubeMetaListOptions(), func(item metav1.PartialObjectMetadata) error {
+		// only uid and annotations are used for the definitions
+		resource := unstructured.Unstructured{Object: map[string]interface{}{}}
+		resource.SetUID(item.GetUID())
+		resource.SetAnnotations(item.GetAnnotations())
+
+		metricConfigList, err := resourceConfig.AnnotationMetrics.MetricsForObject(resource)
+		if err != nil {
+			return nil
+		}
+
+		for _, metricConfig := range metricConfigList {
+			if err := m.registerDynamicMetric(metricConfig); err != nil {
+				logger.Debug("unable to register annotation metric", slog.String("metric", metricConfig.Name), slog.Any("error", err))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Warn("unable to register metrics defined by annotations", slog.Any("error", err))
+	}
+}
+
+// collectResourceAnnotationMetrics collects metrics which are defined by the object itself (via annotation)
+func (m *MetricsCollectorKubeResources) collectResourceAnnotationMetrics(resourceConfig *config.ConfigResource, resource unstructured.Unstructured, objectElement config.ObjectElement, logger *slog.Logger, callback chan<- func()) {
+	resourceLogger := logger.With(
+		slog.String("resource", fmt.Sprintf("%s/%s", resource.GetNamespace(), resource.GetName())),
+		slog.String("annotation", resourceConfig.AnnotationMetrics.Annotation),
+	)
+
+	metricConfigList, err := resourceConfig.AnnotationMetrics.MetricsForObject(resource)
+	if err == nil {
+		for _, metricConfig := range metricConfigList {
+			if err = m.registerDynamicMetric(metricConfig); err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		resourceLogger.Warn("invalid metric definitions in annotation", slog.Any("error", err))
+		m.metricList(metricNameAnnotationMetricsInvalid).Add(m.baseLabels(resource), 1)
+		eventRecorder.Eventf(
+			&resource,
+			corev1.EventTypeWarning,
+			eventReasonInvalidMetricAnnotation,
+			`invalid metric definitions in annotation "%s": %v`,
+			resourceConfig.AnnotationMetrics.Annotation,
+			err,
+		)
+		return
+	}
+
+	for _, metricConfig := range metricConfigList {
+		metricLogger := resourceLogger.With(
+			slog.String("metric", metricConfig.Name),
+		)
+
+		m.collectResourceMetric(resourceConfig, metricConfig, resource, objectElement, metricLogger, callback)
+	}
+}
@@ -1,72 +1,218 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"strings"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/remeh/sizedwaitgroup"
 	"github.com/webdevops/go-common/prometheus/collector"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/webdevops/kube-resource-exporter/config"
 )
 
+const (
+	metricNameConversionFailed = config.METRIC_NAME_CONVERSION_FAILED
+)
+
 type (
 	MetricsCollectorKubeResources struct {
 		collector.Processor
 
 		prometheus struct {
 			metric map[string]*prometheus.GaugeVec
+
+			// metrics calculated relative to the scrape time (age, timeUntil)
+			relative *relativeTimeCollector
+
+			// label signatures of metrics registered at runtime (eg. annotation metrics)
+			dynamicMetric map[string]string
+			lock          sync.RWMutex
 		}
+
+		// owner objects listed for owner labels
+		owners *ownerCache
+
+		// related objects listed for joins
+		joins *joinCache
+
+		// targets listed for reference metrics
+		references *referenceCache
 	}
 )
 
 func (m *MetricsCollectorKubeResources) Setup(collector *collector.Collector) {
 	m.Processor.Setup(collector)
 
-	baseLabels := []string{}
+	m.prometheus.metric = map[string]*prometheus.GaugeVec{}
+	m.prometheus.dynamicMetric = map[string]string{}
+	m.prometheus.relative = newRelativeTimeCollector()
+	prometheus.MustRegister(m.prometheus.relative)
+	m.owners = newOwnerCache(kubeOwnerLister{})
+	m.joins = newJoinCache(m.Logger())
+	m.references = newReferenceCache()
+
+	// generate metric gauges
+	for _, resourceConfig := range exporterConfig.Resources {
+		for _, metricConfig := range resourceConfig.AllMetrics() {
+			m.registerMetricVec(
+				metricConfig,
+				append(
+					m.baseLabelNames(),
+					metricConfig.LabelNames()...,
+				),
+			)
+		}
+	}
+
+	// self metrics
+	gaugeVec := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: metricNameAnnotationMetricsInvalid,
+			Help: "Objects with invalid metric definitions in annotations",
+		},
+		m.baseLabelNames(),
+	)
+	m.Collector.RegisterMetricList(metricNameAnnotationMetricsInvalid, gaugeVec, true)
+	m.prometheus.metric[metricNameAnnotationMetricsInvalid] = gaugeVec
+
+	gaugeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: metricNameConversionFailed,
+			Help: "Objects with data which could not be converted (eg. invalid certificates)",
+		},
+		append(m.baseLabelNames(), "metric", "conversion"),
+	)
+	m.Collector.RegisterMetricList(metricNameConversionFailed, gaugeVec, true)
+	m.prometheus.metric[metricNameConversionFailed] = gaugeVec
+
+	// metrics defined by annotations have to be registered before cached metrics are restored
+	for _, resourceConfig := range exporterConfig.Resources {
+		if resourceConfig.AnnotationMetrics.IsEnabled() {
+			m.registerAnnotationMetrics(resourceConfig)
+		}
+	}
+}
+
+// baseLabelNames returns the label names which are added to every resource metric
+func (m *MetricsCollectorKubeResources) baseLabelNames() []string {
+	return Opts.BaseLabelNames()
+}
+
+// baseLabels returns the base labels for a resource
+func (m *MetricsCollectorKubeResources) baseLabels(resource unstructured.Unstructured) map[string]string {
+	metricLabels := map[string]string{}
 
 	if Opts.Metrics.Labels.Gvr != "" {
-		baseLabels = append(baseLabels, Opts.Metrics.Labels.Gvr)
+		metricLabels[Opts.Metrics.Labels.Gvr] = fmt.Sprintf(
+			"%s/%s/%s",
+			resource.GetObjectKind().GroupVersionKind().Group,
+			resource.GetObjectKind().GroupVersionKind().Version,
+			resource.GetObjectKind().GroupVersionKind().Kind,
+		)
 	}
 
 	if Opts.Metrics.Labels.Namespace != "" {
-		baseLabels = append(baseLabels, Opts.Metrics.Labels.Namespace)
+		metricLabels[Opts.Metrics.Labels.Namespace] = resource.GetNamespace()
 	}
 
 	if Opts.Metrics.Labels.Name != "" {
-		baseLabels = append(baseLabels, Opts.Metrics.Labels.Name)
+		metricLabels[Opts.Metrics.Labels.Name] = resource.GetName()
 	}
 
-	m.prometheus.metric = map[string]*prometheus.GaugeVec{}
+	return metricLabels
+}
 
-	// generate metric gauges
-	for _, resourceConfig := range exporterConfig.Resources {
-		for _, metricConfig := range resourceConfig.Metrics {
-			metricName := metricConfig.Name
-			metricLabels := []string{}
-			for labelName := range metricConfig.Labels {
-				metricLabels = append(metricLabels, labelName)
-			}
+// reportConversionError reports conversions which failed because of invalid data as metric,
+// returns false if the error is not a conversion error
+func (m *MetricsCollectorKubeResources) reportConversionError(metricConfig *config.ConfigMetric, resource unstructured.Unstructured, err error, logger *slog.Logger) bool {
+	var convertErr *config.ConvertError
+	if !errors.As(err, &convertErr) {
+		return false
+	}
 
-			gaugeVec := prometheus.NewGaugeVec(
-				prometheus.GaugeOpts{
-					Name: metricName,
-					Help: metricConfig.Help,
-				},
-				append(
-					baseLabels,
-					metricLabels...,
-				),
-			)
-			m.Collector.RegisterMetricList(metricName, gaugeVec, true)
-			m.prometheus.metric[metricName] = gaugeVec
+	logger.Debug("conversion failed", slog.Any("error", err))
+
+	metricLabels := m.baseLabels(resource)
+	metricLabels["metric"] = metricConfig.Name
+	metricLabels["conversion"] = convertErr.Method
+	m.metricList(metricNameConversionFailed).Add(metricLabels, 1)
+
+	return true
+}
+
+// metricList returns the metric list, safe to use while metrics are registered at runtime
+func (m *MetricsCollectorKubeResources) metricList(name string) *collector.MetricList {
+	m.prometheus.lock.RLock()
+	defer m.prometheus.lock.RUnlock()
+
+	return m.Collector.GetMetricList(name)
+}
+
+// registerDynamicMetric registers a metric which is not known at startup (eg. defined by annotations)
+func (m *MetricsCollectorKubeResources) registerDynamicMetric(metricConfig *config.ConfigMetric) error {
+	m.prometheus.lock.Lock()
+	defer m.prometheus.lock.Unlock()
+
+	labelNames := append(m.baseLabelNames(), metricConfig.LabelNames()...)
+	signature := strings.Join(labelNames, ",")
+
+	if _, exists := m.prometheus.metric[metricConfig.Name]; exists {
+		if existingSignature, isDynamic := m.prometheus.dynamicMetric[metricConfig.Name]; !isDynamic {
+			return fmt.Errorf(`metric "%s" is already defined by configuration`, metricConfig.Name)
+		} else if existingSignature != signature {
+			return fmt.Errorf(`metric "%s" is already registered with different labels (%s)`, metricConfig.Name, existingSignature)
 		}
+
+		return nil
+	}
+
+	seenLabels := map[string]bool{}
+	for _, labelName := range labelNames {
+		if seenLabels[labelName] {
+			return fmt.Errorf(`label "%s" of metric "%s" is conflicting with other labels`, labelName, metricConfig.Name)
+		}
+		seenLabels[labelName] = true
 	}
+
+	m.prometheus.dynamicMetric[metricConfig.Name] = signature
+	m.registerMetricVec(metricConfig, labelNames)
+
+	return nil
 }
 
-func (m *MetricsCollectorKubeResources) Reset() {}
+// registerMetricVec registers the metric list of the metric,
+// lists of relative time metrics contain the timestamps and are only served by the relative time collector
+func (m *MetricsCollectorKubeResources) registerMetricVec(metricConfig *config.ConfigMetric, labelNames []string) {
+	gaugeVec := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: metricConfig.Name,
+			Help: metricConfig.Help,
+		},
+		labelNames,
+	)
+	m.Collector.RegisterMetricList(metricConfig.Name, gaugeVec, true)
+	m.prometheus.metric[metricConfig.Name] = gaugeVec
+
+	if relativeTime := metricConfig.Value.RelativeTime(); relativeTime != "" {
+		prometheus.Unregister(gaugeVec)
+		m.prometheus.relative.Register(metricConfig.Name, metricConfig.Help, labelNames, relativeTime, gaugeVec)
+	}
+}
+
+func (m *MetricsCollectorKubeResources) Reset() {
+	m.owners.Reset()
+	m.joins.Reset()
+	m.references.Reset()
+}
 
 func (m *MetricsCollectorKubeResources) Collect(callback chan<- func()) {
 	wg := sizedwaitgroup.New(Opts.Metrics.ListParallelism)
@@ -102,13 +248,20 @@ func (m *MetricsCollectorKubeResources) collectResource(resourceConfig *config.C
 		listOpts.Continue = result.GetContinue()
 
 		for _, resource := range result.Items {
-			for _, metricConfig := range resourceConfig.Metrics {
+			// shared by all metrics of the object (caches eg. the normalized object for jq and decoded documents)
+			objectElement := config.NewObjectElement(resource.Object)
+
+			for _, metricConfig := range resourceConfig.AllMetrics() {
 				metricLogger := logger.With(
 					slog.String("resource", fmt.Sprintf("%s/%s", resource.GetNamespace(), resource.GetName())),
 					slog.String("metric", metricConfig.Name),
 				)
 
-				m.collectResourceMetric(resourceConfig, metricConfig, resource, metricLogger, callback)
+				m.collectResourceMetric(resourceConfig, metricConfig, resource, objectElement, metricLogger, callback)
+			}
+
+			if resourceConfig.AnnotationMetrics.IsEnabled() {
+				m.collectResourceAnnotationMetrics(resourceConfig, resource, objectElement, logger, callback)
 			}
 		}
 
@@ -117,12 +270,82 @@ func (m *MetricsCollectorKubeResources) collectResource(resourceConfig *config.C
 			break
 		}
 	}
+
+	if resourceConfig.AnnotationMetrics.IsEnabled() {
+		resourceConfig.AnnotationMetrics.Cleanup()
+	}
+}
+
+// listResources lists all objects of the resource (using paging) and passes them to callback
+func listResources(ctx context.Context, gvr schema.GroupVersionResource, namespace string, callback func(item unstructured.Unstructured) error) error {
+	listOpts := metav1.ListOptions{}
+	if Opts.Metrics.ListLimit != nil {
+		listOpts.Limit = *Opts.Metrics.ListLimit
+	}
+
+	for {
+		result, err := k8sDyanmicClient.Resource(gvr).Namespace(namespace).List(ctx, listOpts)
+		if err != nil {
+			return fmt.Errorf(`unable to list "%s": %w`, gvr.String(), err)
+		}
+
+		for _, item := range result.Items {
+			if err := callback(item); err != nil {
+				return err
+			}
+		}
+
+		listOpts.Continue = result.GetContinue()
+		if listOpts.Continue == "" {
+			return nil
+		}
+	}
+}
+
+// listResourceMetadata lists the metadata of all objects of the resource (using paging) and passes them to callback
+func listResourceMetadata(ctx context.Context, gvr schema.GroupVersionResource, namespace string, listOpts metav1.ListOptions, callback func(item metav1.PartialObjectMetadata) error) error {
+	if Opts.Metrics.ListLimit != nil {
+		listOpts.Limit = *Opts.Metrics.ListLimit
+	}
+
+	for {
+		result, err := k8sMetadataClient.Resource(gvr).Namespace(namespace).List(ctx, listOpts)
+		if err != nil {
+			return fmt.Errorf(`unable to list "%s": %w`, gvr.String(), err)
+		}
+
+		for _, item := range result.Items {
+			if err := callback(item); err != nil {
+				return err
+			}
+		}
+
+		listOpts.Continue = result.GetContinue()
+		if listOpts.Continue == "" {
+			return nil
+		}
+	}
 }
 
-func (m *MetricsCollectorKubeResources) collectResourceMetric(resourceConfig *config.ConfigResource, metricConfig *config.ConfigMetric, resource unstructured.Unstructured, logger *slog.Logger, callback chan<- func()) {
-	metric := m.Collector.GetMetricList(metricConfig.Name)
+func (m *MetricsCollectorKubeResources) collectResourceMetric(resourceConfig *config.ConfigResource, metricConfig *config.ConfigMetric, resource unstructured.Unstructured, objectElement config.ObjectElement, logger *slog.Logger, callback chan<- func()) {
+	elementList, err := metricConfig.Elements(objectElement)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
 
-	if !metricConfig.IsValidObject(resource) {
+	for _, element := range elementList {
+		elementLogger := logger
+		if element.Key != nil {
+			elementLogger = logger.With(slog.String("key", *element.Key))
+		}
+
+		m.collectResourceMetricElement(resourceConfig, metricConfig, resource, element, elementLogger, callback)
+	}
+}
+
+func (m *MetricsCollectorKubeResources) collectResourceMetricElement(resourceConfig *config.ConfigResource, metricConfig *config.ConfigMetric, resource unstructured.Unstructured, element config.ObjectElement, logger *slog.Logger, callback chan<- func()) {
+	if !metricConfig.IsValidObject(element) {
 		logger.Debug("filtered")
 		return
 	}
@@ -132,63 +355,157 @@ func (m *MetricsCollectorKubeResources) collectResourceMetric(resourceConfig *co
 		metricValue = metricConfig.Value.Value
 	}
 
-	metricLabels := map[string]string{}
-
-	if Opts.Metrics.Labels.Gvr != "" {
-		metricLabels[Opts.Metrics.Labels.Gvr] = fmt.Sprintf(
-			"%s/%s/%s",
-			resource.GetObjectKind().GroupVersionKind().Group,
-			resource.GetObjectKind().GroupVersionKind().Version,
-			resource.GetObjectKind().GroupVersionKind().Kind,
-		)
+	metricLabels := m.baseLabels(resource)
+	for labelName, labelValue := range metricConfig.KeyLabels(element) {
+		metricLabels[labelName] = labelValue
 	}
 
-	if Opts.Metrics.Labels.Namespace != "" {
-		metricLabels[Opts.Metrics.Labels.Namespace] = resource.GetNamespace()
-	}
+	// referenced object, value is 1 if the target exists
+	if reference := metricConfig.Reference; reference != nil {
+		namespace, name, found, err := reference.Target(element)
+		if err != nil {
+			logger.Error(err.Error())
+			return
+		} else if !found {
+			logger.Debug("no reference found")
+			return
+		}
 
-	if Opts.Metrics.Labels.Name != "" {
-		metricLabels[Opts.Metrics.Labels.Name] = resource.GetName()
+		exists, err := m.references.Exists(m.Context(), reference, namespace, name)
+		if err != nil {
+			logger.Error(err.Error())
+			return
+		}
+
+		metricLabels[config.REFERENCE_LABEL_NAME] = name
+		metricLabels[config.REFERENCE_LABEL_NAMESPACE] = namespace
+
+		value := float64(0)
+		if exists {
+			value = 1
+		}
+		metricValue = &value
 	}
 
-	// find value
-	if valuePath := metricConfig.Value.JsonPath(); valuePath != nil {
-		if results, err := valuePath.FindResults(resource.Object); err == nil {
-			if len(results) == 1 && len(results[0]) == 1 {
-				val := results[0][0].Interface()
+	// find labels
+	for labelName, labelConfig := range metricConfig.Labels {
+		metricLabels[labelName] = labelConfig.Value
+
+		if labelConfig.Owner != nil {
+			owner, err := m.owners.Resolve(m.Context(), resource, labelConfig.Owner)
+			if err != nil {
+				// keep static value, the series is still useful without owner
+				logger.Warn("unable to resolve owner", slog.String("label", labelName), slog.Any("error", err))
+				continue
+			}
 
-				if v := metricConfig.Value.ParseValue(val); v != nil {
-					metricValue = v
+			if owner != nil {
+				if val, err := labelConfig.OwnerLabel(owner.Kind, owner.Name); err == nil {
+					metricLabels[labelName] = val
+				} else if m.reportConversionError(metricConfig, resource, err, logger) {
+					return
+				} else {
+					logger.Error(err.Error())
+					return
 				}
 			}
+			continue
+		}
+
+		if val, found, err := labelConfig.FindLabel(element); err == nil {
+			if found {
+				metricLabels[labelName] = val
+			}
+		} else if m.reportConversionError(metricConfig, resource, err, logger) {
+			return
 		} else {
 			logger.Error(err.Error())
 			return
 		}
 	}
 
-	// find labels
-	for labelName, labelConfig := range metricConfig.Labels {
-		metricLabels[labelName] = labelConfig.Value
+	// find labels from maps (eg. Kubernetes labels and annotations)
+	for _, labelsFromConfig := range metricConfig.LabelsFrom {
+		if labels, err := labelsFromConfig.FindLabels(element); err == nil {
+			for labelName, labelValue := range labels {
+				metricLabels[labelName] = labelValue
+			}
+		} else {
+			logger.Error(err.Error())
+			return
+		}
+	}
 
-		if labelPath := labelConfig.JsonPath(); labelPath != nil {
-			if results, err := labelPath.FindResults(resource.Object); err == nil {
-				if len(results) == 1 && len(results[0]) == 1 {
-					val := results[0][0].Interface()
+	// labels of related objects
+	if joins := metricConfig.Joins(); len(joins) > 0 {
+		if labels, err := m.joins.Labels(m.Context(), resource, joins); err == nil {
+			maps.Copy(metricLabels, labels)
+		} else if m.reportConversionError(metricConfig, resource, err, logger) {
+			return
+		} else {
+			logger.Error(err.Error())
+			return
+		}
+	}
 
-					metricLabels[labelName] = labelConfig.ParseLabel(val)
-				}
-			} else {
-				logger.Error(err.Error())
+	// render label templates
+	templateLabels := maps.Clone(metricLabels)
+	for labelName, labelConfig := range metricConfig.Labels {
+		if labelConfig.HasTemplate() {
+			val, err := labelConfig.RenderTemplate(config.TemplateData{
+				Value:   templateLabels[labelName],
+				Object:  element.Root,
+				Element: element.Value,
+				Labels:  templateLabels,
+			})
+			if err != nil {
+				logger.Error(err.Error(), slog.String("label", labelName))
 				return
 			}
+
+			metricLabels[labelName] = val
 		}
 	}
 
-	// process metric
-	if metricValue != nil {
-		metric.Add(metricLabels, *metricValue)
+	// find value
+	if v, err := metricConfig.Value.FindValue(element); err == nil {
+		if v != nil {
+			metricValue = v
+		}
+	} else if errors.Is(err, config.ErrDivisionByZero) {
+		logger.Debug("value skipped", slog.Any("error", err))
+		return
+	} else if m.reportConversionError(metricConfig, resource, err, logger) {
+		return
 	} else {
+		logger.Error(err.Error())
+		return
+	}
+
+	// render value template
+	if metricConfig.Value.HasTemplate() {
+		var templateValue interface{}
+		if metricValue != nil {
+			templateValue = *metricValue
+		}
+
+		v, err := metricConfig.Value.RenderValueTemplate(config.TemplateData{
+			Value:   templateValue,
+			Object:  element.Root,
+			Element: element.Value,
+			Labels:  metricLabels,
+		})
+		if err != nil {
+			logger.Error(err.Error())
+			return
+		}
+		metricValue = v
+	}
+
+	// process metric
+	if metricValue == nil {
 		logger.Debug("no value found")
+	} else {
+		m.metricList(metricConfig.Name).Add(metricLabels, *metricValue)
 	}
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/webdevops/kube-resource-exporter/config"
+)
+
+type (
+	// joinCache caches the labels of related objects for one collect run
+	joinCache struct {
+		lock    sync.Mutex
+		indexes map[*config.ConfigResourceJoin]*joinIndex
+		logger  *slog.Logger
+	}
+
+	joinIndex struct {
+		once sync.Once
+		err  error
+
+		// labels of the related objects by key
+		labels map[string]map[string]string
+	}
+)
+
+func newJoinCache(logger *slog.Logger) *joinCache {
+	return &joinCache{
+		indexes: map[*config.ConfigResourceJoin]*joinIndex{},
+		logger:  logger,
+	}
+}
+
+// Reset clears the listed related objects
+func (c *joinCache) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.indexes = map[*config.ConfigResourceJoin]*joinIndex{}
+}
+
+// Labels returns the labels of all joins of the metric for the object, default labels are used if no related object was found
+func (c *joinCache) Labels(ctx context.Context, resource unstructured.Unstructured, joins []*config.ConfigResourceJoin) (map[string]string, error) {
+	ret := map[string]string{}
+	for _, join := range joins {
+		labels := join.DefaultLabels()
+
+		key, found, err := join.ObjectKey(resource.Object)
+		if err != nil {
+			return nil, err
+		}
+
+		if found {
+			index, err := c.index(ctx, join)
+			if err != nil {
+				return nil, err
+			}
+
+			if related, exists := index.labels[key]; exists {
+				labels = related
+			}
+		}
+
+		for labelName, labelValue := range labels {
+			ret[labelName] = labelValue
+		}
+	}
+
+	return ret, nil
+}
+
+// index lists the related objects once per collect run and indexes their labels by key,
+// related objects with invalid keys or labels are skipped (objects referencing them get the default labels)
+func (c *joinCache) index(ctx context.Context, join *config.ConfigResourceJoin) (*joinIndex, error) {
+	c.lock.Lock()
+	index, exists := c.indexes[join]
+	if !exists {
+		index = &joinIndex{}
+		c.indexes[join] = index
+	}
+	c.lock.Unlock()
+
+	index.once.Do(func() {
+		index.labels = map[string]map[string]string{}
+		index.err = listResources(ctx, *join.GroupVersionResource, "", func(item unstructured.Unstructured) error {
+			key, found, err := join.RelatedObjectKey(item.Object)
+			if err == nil && found {
+				var labels map[string]string
+				if labels, err = join.FindLabels(item.Object); err == nil {
+					index.labels[key] = labels
+				}
+			}
+
+			if err != nil {
+				c.logger.Debug(
+					"related object skipped",
+					slog.String("gvr", join.GroupVersionResource.String()),
+					slog.String("resource", item.GetNamespace()+"/"+item.GetName()),
+					slog.Any("error", err),
+				)
+			}
+
+			return nil
+		})
+	})
+
+	return index, index.err
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/webdevops/kube-resource-exporter/config"
+)
+
+type (
+	// ownerCache caches the controller ownerReferences of listed owner objects for one collect run
+	ownerCache struct {
+		lock   sync.Mutex
+		lists  map[string]*ownerList
+		lister ownerLister
+	}
+
+	// ownerLister finds and lists the owner resources
+	ownerLister interface {
+		// Resource maps the apiVersion and kind of the ownerReference to the resource
+		Resource(owner *metav1.OwnerReference) (schema.GroupVersionResource, error)
+
+		// List lists the metadata of all objects of the resource (cluster-wide)
+		List(ctx context.Context, gvr schema.GroupVersionResource, callback func(item metav1.PartialObjectMetadata) error) error
+	}
+
+	// kubeOwnerLister uses the rest mapper and the metadata client
+	kubeOwnerLister struct{}
+
+	ownerList struct {
+		once sync.Once
+		err  error
+
+		// controller ownerReference (nil if none) of each object by uid
+		controllers map[types.UID]*metav1.OwnerReference
+	}
+)
+
+func newOwnerCache(lister ownerLister) *ownerCache {
+	return &ownerCache{
+		lists:  map[string]*ownerList{},
+		lister: lister,
+	}
+}
+
+// Reset clears the listed owner objects
+func (c *ownerCache) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lists = map[string]*ownerList{}
+}
+
+// Resolve follows the controller ownerReferences of the resource up to the configured depth (or the root owner),
+// the chain ends early if an owner has no controller, is not found or was already visited (ownerReference cycle);
+// returns nil if the resource has no controller owner
+func (c *ownerCache) Resolve(ctx context.Context, resource unstructured.Unstructured, ownerConfig *config.ConfigMetricLabelOwner) (*metav1.OwnerReference, error) {
+	maxDepth := ownerConfig.MaxDepth()
+
+	visited := map[types.UID]bool{resource.GetUID(): true}
+	owner := metav1.GetControllerOfNoCopy(&resource)
+	for depth := 1; owner != nil && depth < maxDepth; depth++ {
+		visited[owner.UID] = true
+
+		gvr, err := c.lister.Resource(owner)
+		if err != nil {
+			return nil, err
+		}
+
+		list, err := c.list(ctx, gvr)
+		if err != nil {
+			return nil, err
+		}
+
+		next, exists := list.controllers[owner.UID]
+		if !exists || next == nil || visited[next.UID] {
+			// owner not found (eg. already deleted), owner is the root or ownerReferences are cyclic
+			break
+		}
+		owner = next
+	}
+
+	return owner, nil
+}
+
+// list lists the metadata of all objects of the resource (cluster-wide) once per collect run and indexes them by uid
+func (c *ownerCache) list(ctx context.Context, gvr schema.GroupVersionResource) (*ownerList, error) {
+	cacheKey := gvr.String()
+
+	c.lock.Lock()
+	list, exists := c.lists[cacheKey]
+	if !exists {
+		list = &ownerList{}
+		c.lists[cacheKey] = list
+	}
+	c.lock.Unlock()
+
+	list.once.Do(func() {
+		list.controllers = map[types.UID]*metav1.OwnerReference{}
+		list.err = c.lister.List(ctx, gvr, func(item metav1.PartialObjectMetadata) error {
+			list.controllers[item.GetUID()] = metav1.GetControllerOf(&item)
+			return nil
+		})
+	})
+
+	return list, list.err
+}
+
+// Resource maps the apiVersion and kind of the ownerReference to the resource
+func (kubeOwnerLister) Resource(owner *metav1.OwnerReference) (schema.GroupVersionResource, error) {
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, fmt.Errorf(`invalid ownerReference apiVersion "%s": %w`, owner.APIVersion, err)
+	}
+
+	mapping, err := k8sRestMapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: owner.Kind}, gv.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, fmt.Errorf(`unable to find resource for ownerReference "%s/%s": %w`, owner.APIVersion, owner.Kind, err)
+	}
+
+	return mapping.Resource, nil
+}
+
+// List lists the metadata of all objects of the resource (cluster-wide)
+func (kubeOwnerLister) List(ctx context.Context, gvr schema.GroupVersionResource, callback func(item metav1.PartialObjectMetadata) error) error {
+	return listResourceMetadata(ctx, gvr, metav1.NamespaceAll, metav1.ListOptions{}, callback)
+}
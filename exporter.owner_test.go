@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/webdevops/kube-resource-exporter/config"
+)
+
+type (
+	// fakeOwnerLister returns the objects by kind, resources are named after the kind
+	fakeOwnerLister struct {
+		objects map[string][]metav1.PartialObjectMetadata
+		err     error
+		lists   int
+	}
+)
+
+func (l *fakeOwnerLister) Resource(owner *metav1.OwnerReference) (schema.GroupVersionResource, error) {
+	if _, exists := l.objects[owner.Kind]; !exists {
+		return schema.GroupVersionResource{}, fmt.Errorf(`unable to find resource for ownerReference "%s/%s"`, owner.APIVersion, owner.Kind)
+	}
+
+	return schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: owner.Kind}, nil
+}
+
+func (l *fakeOwnerLister) List(ctx context.Context, gvr schema.GroupVersionResource, callback func(item metav1.PartialObjectMetadata) error) error {
+	l.lists++
+	if l.err != nil {
+		return l.err
+	}
+
+	for _, item := range l.objects[gvr.Resource] {
+		if err := callback(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func fakeOwnerObject(kind, name string, owner *metav1.PartialObjectMetadata) metav1.PartialObjectMetadata {
+	object := metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: kind},
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(kind + "/" + name)},
+	}
+
+	if owner != nil {
+		object.OwnerReferences = []metav1.OwnerReference{fakeOwnerReference(*owner)}
+	}
+
+	return object
+}
+
+func fakeOwnerReference(owner metav1.PartialObjectMetadata) metav1.OwnerReference {
+	controller := true
+	return metav1.OwnerReference{
+		APIVersion: owner.APIVersion,
+		Kind:       owner.Kind,
+		Name:       owner.Name,
+		UID:        owner.UID,
+		Controller: &controller,
+	}
+}
+
+func fakeOwnerResource(owner *metav1.PartialObjectMetadata) unstructured.Unstructured {
+	resource := unstructured.Unstructured{Object: map[string]interface{}{}}
+	resource.SetAPIVersion("v1")
+	resource.SetKind("Pod")
+	resource.SetName("app-7d4b9c-x2x4z")
+	resource.SetUID("Pod/app-7d4b9c-x2x4z")
+
+	if owner != nil {
+		resource.SetOwnerReferences([]metav1.OwnerReference{fakeOwnerReference(*owner)})
+	}
+
+	return resource
+}
+
+func TestOwnerCacheResolve(t *testing.T) {
+	deployment := fakeOwnerObject("Deployment", "app", nil)
+	replicaSet := fakeOwnerObject("ReplicaSet", "app-7d4b9c", &deployment)
+	orphanReplicaSet := fakeOwnerObject("ReplicaSet", "orphan-5f6c8d", nil)
+	deletedReplicaSet := fakeOwnerObject("ReplicaSet", "deleted-8c9d7e", &deployment)
+
+	// ownerReferences pointing to each other (a -> b -> c -> a)
+	cycleC := fakeOwnerObject("CycleC", "c", nil)
+	cycleB := fakeOwnerObject("CycleB", "b", &cycleC)
+	cycleA := fakeOwnerObject("CycleA", "a", &cycleB)
+	cycleC.OwnerReferences = []metav1.OwnerReference{fakeOwnerReference(cycleA)}
+
+	// ownerReference pointing back to the resource
+	selfOwner := fakeOwnerObject("Self", "self", nil)
+	pod := fakeOwnerResource(&selfOwner)
+	selfOwner.OwnerReferences = pod.GetOwnerReferences()
+	selfOwner.OwnerReferences[0].Kind = "Pod"
+	selfOwner.OwnerReferences[0].Name = pod.GetName()
+	selfOwner.OwnerReferences[0].UID = pod.GetUID()
+
+	objects := map[string][]metav1.PartialObjectMetadata{
+		"Deployment": {deployment},
+		"ReplicaSet": {replicaSet, orphanReplicaSet},
+		"CycleA":     {cycleA},
+		"CycleB":     {cycleB},
+		"CycleC":     {cycleC},
+		"Self":       {selfOwner},
+	}
+
+	tests := []struct {
+		name     string
+		resource unstructured.Unstructured
+		depth    *int
+		expected string
+	}{
+		{name: "root", resource: fakeOwnerResource(&replicaSet), expected: "Deployment/app"},
+		{name: "direct owner", resource: fakeOwnerResource(&replicaSet), depth: intPtr(1), expected: "ReplicaSet/app-7d4b9c"},
+		{name: "depth", resource: fakeOwnerResource(&replicaSet), depth: intPtr(2), expected: "Deployment/app"},
+		{name: "depth above root", resource: fakeOwnerResource(&replicaSet), depth: intPtr(5), expected: "Deployment/app"},
+		{name: "owner without controller", resource: fakeOwnerResource(&orphanReplicaSet), expected: "ReplicaSet/orphan-5f6c8d"},
+		{name: "missing owner", resource: fakeOwnerResource(&deletedReplicaSet), expected: "ReplicaSet/deleted-8c9d7e"},
+		{name: "no owner", resource: fakeOwnerResource(nil), expected: ""},
+		{name: "cycle", resource: fakeOwnerResource(&cycleA), expected: "CycleC/c"},
+		{name: "cycle to resource", resource: pod, expected: "Self/self"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lister := &fakeOwnerLister{objects: objects}
+			cache := newOwnerCache(lister)
+
+			ownerConfig := &config.ConfigMetricLabelOwner{Depth: test.depth}
+			if err := ownerConfig.Compile(); err != nil {
+				t.Fatal(err)
+			}
+
+			owner, err := cache.Resolve(context.Background(), test.resource, ownerConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resolved := ""
+			if owner != nil {
+				resolved = owner.Kind + "/" + owner.Name
+			}
+
+			if resolved != test.expected {
+				t.Errorf("expected owner %q, got %q", test.expected, resolved)
+			}
+
+			if lister.lists > config.OWNER_MAX_DEPTH {
+				t.Errorf("expected at most %d lists, got %d", config.OWNER_MAX_DEPTH, lister.lists)
+			}
+		})
+	}
+}
+
+func TestOwnerCacheResolveErrors(t *testing.T) {
+	deployment := fakeOwnerObject("Deployment", "app", nil)
+	replicaSet := fakeOwnerObject("ReplicaSet", "app-7d4b9c", &deployment)
+	ownerConfig := &config.ConfigMetricLabelOwner{}
+	if err := ownerConfig.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	// unknown owner kind
+	lister := &fakeOwnerLister{objects: map[string][]metav1.PartialObjectMetadata{}}
+	if _, err := newOwnerCache(lister).Resolve(context.Background(), fakeOwnerResource(&replicaSet), ownerConfig); err == nil || !strings.Contains(err.Error(), `unable to find resource for ownerReference "apps/v1/ReplicaSet"`) {
+		t.Errorf("expected resource error, got %v", err)
+	}
+
+	// list failure is cached for the collect run
+	lister = &fakeOwnerLister{objects: map[string][]metav1.PartialObjectMetadata{"ReplicaSet": {replicaSet}}, err: errors.New("forbidden")}
+	cache := newOwnerCache(lister)
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Resolve(context.Background(), fakeOwnerResource(&replicaSet), ownerConfig); err == nil || err.Error() != "forbidden" {
+			t.Errorf("expected list error, got %v", err)
+		}
+	}
+	if lister.lists != 1 {
+		t.Errorf("expected 1 list, got %d", lister.lists)
+	}
+
+	// reset lists again
+	cache.Reset()
+	if _, err := cache.Resolve(context.Background(), fakeOwnerResource(&replicaSet), ownerConfig); err == nil {
+		t.Errorf("expected list error")
+	}
+	if lister.lists != 2 {
+		t.Errorf("expected 2 lists, got %d", lister.lists)
+	}
+}
+
+func intPtr(val int) *int {
+	return &val
+}
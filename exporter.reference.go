@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/webdevops/kube-resource-exporter/config"
+)
+
+type (
+	// referenceCache caches the existing reference targets for one collect run
+	referenceCache struct {
+		lock    sync.Mutex
+		indexes map[string]*referenceIndex
+	}
+
+	referenceIndex struct {
+		once sync.Once
+		err  error
+
+		// existing objects by namespace/name
+		objects map[string]bool
+	}
+)
+
+func newReferenceCache() *referenceCache {
+	return &referenceCache{
+		indexes: map[string]*referenceIndex{},
+	}
+}
+
+// Reset clears the listed reference targets
+func (c *referenceCache) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.indexes = map[string]*referenceIndex{}
+}
+
+// Exists returns true if the referenced object exists, target metadata is listed once per collect run and resource
+func (c *referenceCache) Exists(ctx context.Context, reference *config.ConfigMetricReference, namespace, name string) (bool, error) {
+	gvr := *reference.GroupVersionResource
+	cacheKey := gvr.String()
+
+	c.lock.Lock()
+	index, exists := c.indexes[cacheKey]
+	if !exists {
+		index = &referenceIndex{}
+		c.indexes[cacheKey] = index
+	}
+	c.lock.Unlock()
+
+	index.once.Do(func() {
+		index.objects = map[string]bool{}
+		// only metadata is needed, targets can be large or sensitive (eg. Secrets)
+		index.err = listResourceMetadata(ctx, gvr, metav1.NamespaceAll, metav1.ListOptions{}, func(item metav1.PartialObjectMetadata) error {
+			index.objects[item.GetNamespace()+"/"+item.GetName()] = true
+			return nil
+		})
+	})
+
+	if index.err != nil {
+		return false, index.err
+	}
+
+	return index.objects[namespace+"/"+name], nil
+}
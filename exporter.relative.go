@@ -0,0 +1,90 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/webdevops/kube-resource-exporter/config"
+)
+
+type (
+	// relativeTimeCollector exports metrics relative to the scrape time (age, timeUntil),
+	// timestamps are collected into regular metric lists (which are cached and restored like other metrics)
+	// but the vecs are not exposed, the values are calculated from them when metrics are served
+	relativeTimeCollector struct {
+		lock    sync.RWMutex
+		metrics map[string]*relativeTimeMetric
+	}
+
+	relativeTimeMetric struct {
+		desc         *prometheus.Desc
+		labelNames   []string
+		relativeTime string
+		vec          *prometheus.GaugeVec
+	}
+)
+
+func newRelativeTimeCollector() *relativeTimeCollector {
+	return &relativeTimeCollector{
+		metrics: map[string]*relativeTimeMetric{},
+	}
+}
+
+// Register registers a new relative time metric, vec contains the timestamps and must not be registered itself
+func (c *relativeTimeCollector) Register(name, help string, labelNames []string, relativeTime string, vec *prometheus.GaugeVec) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.metrics[name] = &relativeTimeMetric{
+		desc:         prometheus.NewDesc(name, help, labelNames, nil),
+		labelNames:   labelNames,
+		relativeTime: relativeTime,
+		vec:          vec,
+	}
+}
+
+// Describe is empty as metrics are registered at runtime (unchecked collector)
+func (c *relativeTimeCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect calculates the values relative to the current time
+func (c *relativeTimeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	now := float64(time.Now().Unix())
+	for _, metric := range c.metrics {
+		timestamps := make(chan prometheus.Metric)
+		go func() {
+			metric.vec.Collect(timestamps)
+			close(timestamps)
+		}()
+
+		for timestampMetric := range timestamps {
+			row := &dto.Metric{}
+			if err := timestampMetric.Write(row); err != nil {
+				continue
+			}
+
+			labels := map[string]string{}
+			for _, label := range row.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			labelValues := make([]string, len(metric.labelNames))
+			for num, labelName := range metric.labelNames {
+				labelValues[num] = labels[labelName]
+			}
+
+			timestamp := row.GetGauge().GetValue()
+			value := now - timestamp
+			if metric.relativeTime == config.CONVERT_TIMEUNTIL {
+				value = timestamp - now
+			}
+
+			ch <- prometheus.MustNewConstMetric(metric.desc, prometheus.GaugeValue, value, labelValues...)
+		}
+	}
+}
@@ -16,9 +16,18 @@ import (
 	flags "github.com/jessevdk/go-flags"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/webdevops/go-common/prometheus/collector"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/webdevops/kube-resource-exporter/config"
 )
@@ -32,7 +41,11 @@ var (
 	argparser *flags.Parser
 	Opts      config.Opts
 
-	k8sDyanmicClient dynamic.Interface
+	k8sDyanmicClient  dynamic.Interface
+	k8sClient         kubernetes.Interface
+	k8sMetadataClient metadata.Interface
+	k8sRestMapper     meta.RESTMapper
+	eventRecorder     record.EventRecorder
 
 	// cache config
 	cacheTag = "v2"
@@ -92,7 +105,7 @@ func initConfig(path string) {
 		logger.Fatal(err.Error())
 	}
 
-	if err := exporterConfig.Compile(); err != nil {
+	if err := exporterConfig.Compile(Opts.BaseLabelNames()); err != nil {
 		logger.Fatal(err.Error())
 	}
 }
@@ -121,6 +134,25 @@ func initKubeConnection() {
 		panic(err)
 	}
 
+	k8sClient, err = kubernetes.NewForConfig(config)
+	if err != nil {
+		panic(err)
+	}
+
+	// metadata only lists (eg. for existence checks)
+	k8sMetadataClient, err = metadata.NewForConfig(config)
+	if err != nil {
+		panic(err)
+	}
+
+	// mapping of ownerReferences (apiVersion, kind) to resources
+	k8sRestMapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(k8sClient.Discovery()))
+
+	// event recorder
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k8sClient.CoreV1().Events("")})
+	eventRecorder = eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "kube-resource-exporter"})
+
 	// kube logger
 	logrHandler := logr.NewContextWithSlogLogger(context.Background(), logger.Slog())
 	kubeLogger, err := logr.FromContext(logrHandler)